@@ -0,0 +1,132 @@
+package mac
+
+import "unsafe"
+
+// backend describes the native operations the driver relies on.
+// The Go side of the driver only talks to the native layer through it, which
+// allows it to run without Cocoa.
+type backend interface {
+	Run()
+	Terminate()
+	OSVersion() osVersion
+	SetMenuBar(menu unsafe.Pointer)
+	SetDockMenu(menu unsafe.Pointer)
+	SetDockIcon(path string)
+	SetDockBadge(badge string)
+
+	WindowNew(w windowSpec)
+	WindowShow(win unsafe.Pointer)
+	WindowFocus(win unsafe.Pointer)
+	WindowEvalJS(win unsafe.Pointer, callID string, js string)
+	WindowLoadHTML(win unsafe.Pointer, html string, baseURL string)
+	WindowSetTitle(win unsafe.Pointer, title string)
+	WindowSetBackgroundColor(win unsafe.Pointer, color string)
+	WindowSetVibrancy(win unsafe.Pointer, vibrancy int)
+	WindowSetResizable(win unsafe.Pointer, resizable bool)
+	WindowSetMinMaxSize(win unsafe.Pointer, minWidth, minHeight, maxWidth, maxHeight float64)
+	WindowConfirm(win unsafe.Pointer, message string) bool
+	WindowPrompt(win unsafe.Pointer, message, defaultText string) (text string, ok bool)
+	WindowFrame(win unsafe.Pointer) (x, y, width, height float64)
+	WindowMove(win unsafe.Pointer, x, y float64)
+	WindowResize(win unsafe.Pointer, width, height float64)
+	WindowMinimize(win unsafe.Pointer)
+	WindowDeminimize(win unsafe.Pointer)
+	WindowZoom(win unsafe.Pointer)
+	WindowCenter(win unsafe.Pointer)
+	WindowToggleFullScreen(win unsafe.Pointer)
+	WindowClose(win unsafe.Pointer)
+	Screens() []screenFrame
+
+	MenuNew(id string) unsafe.Pointer
+	MenuMount(menu unsafe.Pointer, rootID string)
+	MenuShow(menu unsafe.Pointer)
+	MenuMountContainer(menu unsafe.Pointer, c menuContainerSpec)
+	MenuMountItem(menu unsafe.Pointer, i menuItemSpec)
+	MenuInsert(menu unsafe.Pointer, parentID string, childID string, index int)
+	MenuRemove(menu unsafe.Pointer, parentID string, childID string)
+	MenuClear(menu unsafe.Pointer)
+
+	OpenURL(rawurl string)
+	NewFilePicker(p filePickerSpec)
+	ShareText(v string)
+	ShareURL(v string)
+
+	ResourcesDir() string
+	HomeDir() string
+	SupportDir() string
+	BundleID() string
+	BundleName() string
+	IsSandboxed() bool
+}
+
+// windowSpec describes a native window to create.
+type windowSpec struct {
+	ID              string
+	Title           string
+	X               float64
+	Y               float64
+	Width           float64
+	Height          float64
+	MinWidth        float64
+	MinHeight       float64
+	MaxWidth        float64
+	MaxHeight       float64
+	BackgroundColor string
+	Vibrancy        int
+	Borderless      bool
+	FixedSize       bool
+	CloseHidden     bool
+	MinimizeHidden  bool
+	TitlebarHidden  bool
+	HTML            string
+	BaseURL         string
+	ResourceScheme  string
+	UserScript      string
+}
+
+// screenFrame describes the area of a screen where windows can be displayed.
+type screenFrame struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// menuContainerSpec describes a native menu container.
+type menuContainerSpec struct {
+	ID      string
+	Label   string
+	OnOpen  string
+	OnClose string
+	Role    string
+}
+
+// menuItemSpec describes a native menu item.
+type menuItemSpec struct {
+	ID        string
+	Label     string
+	Icon      string
+	Selector  string
+	OnClick   string
+	Disabled  bool
+	Separator bool
+
+	// KeyEquivalent and KeyModifiers describe the shortcut of the item.
+	KeyEquivalent string
+	KeyModifiers  ShortcutModifier
+
+	// State is displayed when Checkable is true. Group and Value are only
+	// used by the driver.
+	Checkable bool
+	State     menuItemState
+	Group     string
+	Value     string
+}
+
+// filePickerSpec describes a native file picker.
+type filePickerSpec struct {
+	ID                string
+	MultipleSelection bool
+	NoDir             bool
+	NoFile            bool
+}
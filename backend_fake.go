@@ -0,0 +1,991 @@
+package mac
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/satori/go.uuid"
+)
+
+// fakeBackend is a pure Go backend that keeps the native state in memory and
+// records the calls it receives.
+// Operations that Cocoa performs on the main queue are executed in order on
+// a dedicated goroutine, which also emits the callbacks Cocoa would emit.
+type fakeBackend struct {
+	mutex     sync.Mutex
+	queue     chan func()
+	done      chan struct{}
+	recording bool
+	stalled   bool
+	calls     []fakeCall
+	windows   map[unsafe.Pointer]*fakeWindow
+	menus     map[unsafe.Pointer]*fakeMenu
+	pickers   map[string]filePickerSpec
+	keyWindow unsafe.Pointer
+	menuBar   unsafe.Pointer
+	dockMenu  unsafe.Pointer
+	dockIcon  string
+	dockBadge string
+	evalJS    func(js string) (result json.RawMessage, exception string)
+	screens   []screenFrame
+	osVersion osVersion
+	confirm   func(message string) bool
+	prompt    func(message, defaultText string) (text string, ok bool)
+}
+
+// fakeCall is a call recorded by a fakeBackend.
+type fakeCall struct {
+	Name string
+	Args []interface{}
+}
+
+type fakeWindow struct {
+	spec       windowSpec
+	x          float64
+	y          float64
+	width      float64
+	height     float64
+	visible    bool
+	minimized  bool
+	fullScreen bool
+	zoomed     *screenFrame
+	scripts    []string
+}
+
+type fakeMenu struct {
+	id       string
+	root     string
+	shown    bool
+	updating bool
+	elems    map[string]*fakeMenuElem
+	parent   map[string]string
+}
+
+type fakeMenuElem struct {
+	container menuContainerSpec
+	item      menuItemSpec
+	isItem    bool
+	children  []string
+}
+
+func newFakeBackend() *fakeBackend {
+	b := &fakeBackend{
+		queue:     make(chan func(), 4096),
+		done:      make(chan struct{}),
+		recording: true,
+		windows:   make(map[unsafe.Pointer]*fakeWindow),
+		menus:     make(map[unsafe.Pointer]*fakeMenu),
+		pickers:   make(map[string]filePickerSpec),
+		screens: []screenFrame{
+			{Width: 1920, Height: 1080},
+		},
+		osVersion: osVersion{Major: 14},
+	}
+
+	go func() {
+		for fn := range b.queue {
+			fn()
+		}
+	}()
+	return b
+}
+
+// async enqueues fn the same way Cocoa code is deferred to the main queue.
+func (b *fakeBackend) async(fn func()) {
+	b.queue <- fn
+}
+
+// flush blocks until all the previously enqueued operations are executed.
+// It must not be called from the queue goroutine.
+func (b *fakeBackend) flush() {
+	flushed := make(chan struct{})
+	b.async(func() { close(flushed) })
+	<-flushed
+}
+
+func (b *fakeBackend) record(name string, args ...interface{}) {
+	if !b.recording {
+		return
+	}
+
+	b.mutex.Lock()
+	b.calls = append(b.calls, fakeCall{
+		Name: name,
+		Args: args,
+	})
+	b.mutex.Unlock()
+}
+
+// Calls returns the recorded calls named name.
+func (b *fakeBackend) Calls(name string) []fakeCall {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var calls []fakeCall
+	for _, c := range b.calls {
+		if c.Name == name {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (b *fakeBackend) Run() {
+	b.record("Run")
+	b.async(handleLaunch)
+	<-b.done
+}
+
+func (b *fakeBackend) Terminate() {
+	b.record("Terminate")
+	b.async(func() {
+		if !handleTerminate() {
+			return
+		}
+		handleFinalize()
+
+		select {
+		case <-b.done:
+		default:
+			close(b.done)
+		}
+	})
+}
+
+func (b *fakeBackend) OSVersion() osVersion {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.osVersion
+}
+
+// setOSVersion sets the macOS version reported by the backend.
+func (b *fakeBackend) setOSVersion(v osVersion) {
+	b.mutex.Lock()
+	b.osVersion = v
+	b.mutex.Unlock()
+}
+
+func (b *fakeBackend) SetMenuBar(menu unsafe.Pointer) {
+	b.record("SetMenuBar", menu)
+	b.async(func() {
+		b.mutex.Lock()
+		b.menuBar = menu
+		b.mutex.Unlock()
+	})
+}
+
+func (b *fakeBackend) SetDockMenu(menu unsafe.Pointer) {
+	b.record("SetDockMenu", menu)
+	b.async(func() {
+		b.mutex.Lock()
+		b.dockMenu = menu
+		b.mutex.Unlock()
+	})
+}
+
+func (b *fakeBackend) SetDockIcon(path string) {
+	b.record("SetDockIcon", path)
+	b.async(func() {
+		b.mutex.Lock()
+		b.dockIcon = path
+		b.mutex.Unlock()
+	})
+}
+
+func (b *fakeBackend) SetDockBadge(badge string) {
+	b.record("SetDockBadge", badge)
+	b.async(func() {
+		b.mutex.Lock()
+		b.dockBadge = badge
+		b.mutex.Unlock()
+	})
+}
+
+func (b *fakeBackend) WindowNew(w windowSpec) {
+	b.record("WindowNew", w)
+	if b.stalled {
+		return
+	}
+
+	b.async(func() {
+		win := &fakeWindow{
+			spec:   w,
+			x:      w.X,
+			y:      w.Y,
+			width:  w.Width,
+			height: w.Height,
+		}
+		ptr := unsafe.Pointer(win)
+
+		b.mutex.Lock()
+		b.windows[ptr] = win
+		b.mutex.Unlock()
+
+		id := uuid.FromStringOrNil(w.ID)
+		handleWindowCreated(id, ptr)
+		handleWindowWebviewLoaded(id)
+	})
+}
+
+func (b *fakeBackend) WindowShow(ptr unsafe.Pointer) {
+	b.record("WindowShow", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if ok {
+			win.visible = true
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			b.focus(ptr)
+		}
+	})
+}
+
+func (b *fakeBackend) WindowFocus(ptr unsafe.Pointer) {
+	b.record("WindowFocus", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		var minimized bool
+		if ok {
+			minimized = win.minimized
+			win.visible = true
+			win.minimized = false
+		}
+		b.mutex.Unlock()
+
+		if !ok {
+			return
+		}
+		if minimized {
+			handleWindowDeminimize(uuid.FromStringOrNil(win.spec.ID))
+		}
+		b.focus(ptr)
+	})
+}
+
+func (b *fakeBackend) WindowEvalJS(ptr unsafe.Pointer, callID string, js string) {
+	b.record("WindowEvalJS", ptr, callID, js)
+
+	b.mutex.Lock()
+	if win, ok := b.windows[ptr]; ok && b.recording {
+		win.scripts = append(win.scripts, js)
+	}
+	eval := b.evalJS
+	b.mutex.Unlock()
+
+	b.async(func() {
+		result := json.RawMessage("null")
+		var exception string
+
+		if eval != nil {
+			result, exception = eval(js)
+		}
+		handleWindowJSResult(uuid.FromStringOrNil(callID), result, exception)
+	})
+}
+
+// setEvalJS sets the function that produces the results of the evaluated
+// javascript. By default, evaluations return null.
+func (b *fakeBackend) setEvalJS(eval func(js string) (result json.RawMessage, exception string)) {
+	b.mutex.Lock()
+	b.evalJS = eval
+	b.mutex.Unlock()
+}
+
+func (b *fakeBackend) WindowLoadHTML(ptr unsafe.Pointer, html string, baseURL string) {
+	b.record("WindowLoadHTML", ptr, html, baseURL)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.HTML = html
+		win.spec.BaseURL = baseURL
+	})
+	b.emitWindowEvent(ptr, handleWindowWebviewLoaded)
+}
+
+func (b *fakeBackend) WindowSetTitle(ptr unsafe.Pointer, title string) {
+	b.record("WindowSetTitle", ptr, title)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.Title = title
+	})
+}
+
+func (b *fakeBackend) WindowSetBackgroundColor(ptr unsafe.Pointer, color string) {
+	b.record("WindowSetBackgroundColor", ptr, color)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.BackgroundColor = color
+	})
+}
+
+func (b *fakeBackend) WindowSetVibrancy(ptr unsafe.Pointer, vibrancy int) {
+	b.record("WindowSetVibrancy", ptr, vibrancy)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.Vibrancy = vibrancy
+	})
+}
+
+func (b *fakeBackend) WindowSetResizable(ptr unsafe.Pointer, resizable bool) {
+	b.record("WindowSetResizable", ptr, resizable)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.FixedSize = !resizable
+	})
+}
+
+func (b *fakeBackend) WindowSetMinMaxSize(ptr unsafe.Pointer, minWidth, minHeight, maxWidth, maxHeight float64) {
+	b.record("WindowSetMinMaxSize", ptr, minWidth, minHeight, maxWidth, maxHeight)
+	b.withWindow(ptr, func(win *fakeWindow) {
+		win.spec.MinWidth = minWidth
+		win.spec.MinHeight = minHeight
+		win.spec.MaxWidth = maxWidth
+		win.spec.MaxHeight = maxHeight
+	})
+}
+
+// withWindow asynchronously calls fn with the window pointed by ptr, the
+// same way Cocoa updates windows on the main queue.
+func (b *fakeBackend) withWindow(ptr unsafe.Pointer, fn func(win *fakeWindow)) {
+	b.async(func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if win, ok := b.windows[ptr]; ok {
+			fn(win)
+		}
+	})
+}
+
+// WindowConfirm answers confirmation panels with the fake confirm hook, or
+// cancels them.
+func (b *fakeBackend) WindowConfirm(ptr unsafe.Pointer, message string) bool {
+	b.record("WindowConfirm", ptr, message)
+
+	b.mutex.Lock()
+	confirm := b.confirm
+	b.mutex.Unlock()
+
+	if confirm == nil {
+		return false
+	}
+	return confirm(message)
+}
+
+// WindowPrompt answers prompt panels with the fake prompt hook, or cancels
+// them.
+func (b *fakeBackend) WindowPrompt(ptr unsafe.Pointer, message, defaultText string) (text string, ok bool) {
+	b.record("WindowPrompt", ptr, message, defaultText)
+
+	b.mutex.Lock()
+	prompt := b.prompt
+	b.mutex.Unlock()
+
+	if prompt == nil {
+		return "", false
+	}
+	return prompt(message, defaultText)
+}
+
+// setPanels sets the functions that answer the native confirm and prompt
+// panels.
+func (b *fakeBackend) setPanels(confirm func(message string) bool, prompt func(message, defaultText string) (string, bool)) {
+	b.mutex.Lock()
+	b.confirm = confirm
+	b.prompt = prompt
+	b.mutex.Unlock()
+}
+
+func (b *fakeBackend) WindowFrame(ptr unsafe.Pointer) (x, y, width, height float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if win, ok := b.windows[ptr]; ok {
+		x, y, width, height = win.x, win.y, win.width, win.height
+	}
+	return
+}
+
+func (b *fakeBackend) WindowMove(ptr unsafe.Pointer, x, y float64) {
+	b.record("WindowMove", ptr, x, y)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if ok {
+			win.x, win.y = x, y
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			handleWindowMove(uuid.FromStringOrNil(win.spec.ID), x, y)
+		}
+	})
+}
+
+func (b *fakeBackend) WindowResize(ptr unsafe.Pointer, width, height float64) {
+	b.record("WindowResize", ptr, width, height)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if ok {
+			win.width, win.height = width, height
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			handleWindowResize(uuid.FromStringOrNil(win.spec.ID), width, height)
+		}
+	})
+}
+
+func (b *fakeBackend) WindowMinimize(ptr unsafe.Pointer) {
+	b.record("WindowMinimize", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if ok {
+			ok = !win.minimized
+			win.minimized = true
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			handleWindowMinimize(uuid.FromStringOrNil(win.spec.ID))
+		}
+	})
+}
+
+func (b *fakeBackend) WindowDeminimize(ptr unsafe.Pointer) {
+	b.record("WindowDeminimize", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if ok {
+			ok = win.minimized
+			win.minimized = false
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			handleWindowDeminimize(uuid.FromStringOrNil(win.spec.ID))
+		}
+	})
+}
+
+// WindowZoom fills the first screen with the window, or restores its previous
+// frame when it is already zoomed.
+func (b *fakeBackend) WindowZoom(ptr unsafe.Pointer) {
+	b.record("WindowZoom", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		if !ok {
+			b.mutex.Unlock()
+			return
+		}
+
+		if win.zoomed != nil {
+			frame := *win.zoomed
+			win.x, win.y, win.width, win.height = frame.X, frame.Y, frame.Width, frame.Height
+			win.zoomed = nil
+		} else {
+			screen := b.screens[0]
+			win.zoomed = &screenFrame{X: win.x, Y: win.y, Width: win.width, Height: win.height}
+			win.x, win.y, win.width, win.height = screen.X, screen.Y, screen.Width, screen.Height
+		}
+		x, y, width, height := win.x, win.y, win.width, win.height
+		b.mutex.Unlock()
+
+		id := uuid.FromStringOrNil(win.spec.ID)
+		handleWindowMove(id, x, y)
+		handleWindowResize(id, width, height)
+	})
+}
+
+// WindowCenter moves the window to the center of the first screen.
+func (b *fakeBackend) WindowCenter(ptr unsafe.Pointer) {
+	b.record("WindowCenter", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		var x, y float64
+		if ok {
+			screen := b.screens[0]
+			win.x = screen.X + (screen.Width-win.width)/2
+			win.y = screen.Y + (screen.Height-win.height)/2
+			x, y = win.x, win.y
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			handleWindowMove(uuid.FromStringOrNil(win.spec.ID), x, y)
+		}
+	})
+}
+
+func (b *fakeBackend) WindowToggleFullScreen(ptr unsafe.Pointer) {
+	b.record("WindowToggleFullScreen", ptr)
+	b.async(func() {
+		b.mutex.Lock()
+		win, ok := b.windows[ptr]
+		var fullScreen bool
+		if ok {
+			win.fullScreen = !win.fullScreen
+			fullScreen = win.fullScreen
+		}
+		b.mutex.Unlock()
+
+		if !ok {
+			return
+		}
+		if fullScreen {
+			handleWindowFullScreen(uuid.FromStringOrNil(win.spec.ID))
+			return
+		}
+		handleWindowExitFullScreen(uuid.FromStringOrNil(win.spec.ID))
+	})
+}
+
+func (b *fakeBackend) WindowClose(ptr unsafe.Pointer) {
+	b.record("WindowClose", ptr)
+	b.async(func() {
+		win, ok := b.window(ptr)
+		if !ok {
+			return
+		}
+
+		id := uuid.FromStringOrNil(win.spec.ID)
+		if !handleWindowClose(id) {
+			return
+		}
+		handleWindowCloseFinal(id)
+
+		b.mutex.Lock()
+		delete(b.windows, ptr)
+		if b.keyWindow == ptr {
+			b.keyWindow = nil
+		}
+		b.mutex.Unlock()
+	})
+}
+
+func (b *fakeBackend) Screens() []screenFrame {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]screenFrame(nil), b.screens...)
+}
+
+// setScreens sets the screens reported by the backend.
+func (b *fakeBackend) setScreens(screens ...screenFrame) {
+	b.mutex.Lock()
+	b.screens = screens
+	b.mutex.Unlock()
+}
+
+func (b *fakeBackend) window(ptr unsafe.Pointer) (win *fakeWindow, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	win, ok = b.windows[ptr]
+	return
+}
+
+// scripts returns the javascript evaluated in the window pointed by ptr.
+func (b *fakeBackend) scripts(ptr unsafe.Pointer) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	win, ok := b.windows[ptr]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), win.scripts...)
+}
+
+func (b *fakeBackend) MenuNew(id string) unsafe.Pointer {
+	b.record("MenuNew", id)
+
+	menu := &fakeMenu{
+		id:     id,
+		elems:  make(map[string]*fakeMenuElem),
+		parent: make(map[string]string),
+	}
+	ptr := unsafe.Pointer(menu)
+
+	b.mutex.Lock()
+	b.menus[ptr] = menu
+	b.mutex.Unlock()
+	return ptr
+}
+
+func (b *fakeBackend) MenuMount(ptr unsafe.Pointer, rootID string) {
+	b.record("MenuMount", ptr, rootID)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		m.root = rootID
+	})
+}
+
+func (b *fakeBackend) MenuShow(ptr unsafe.Pointer) {
+	b.record("MenuShow", ptr)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		m.shown = true
+	})
+}
+
+func (b *fakeBackend) MenuMountContainer(ptr unsafe.Pointer, c menuContainerSpec) {
+	b.record("MenuMountContainer", ptr, c)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		elem, ok := m.elems[c.ID]
+		if !ok {
+			m.elems[c.ID] = &fakeMenuElem{container: c}
+			return
+		}
+		elem.container = c
+	})
+}
+
+func (b *fakeBackend) MenuMountItem(ptr unsafe.Pointer, i menuItemSpec) {
+	b.record("MenuMountItem", ptr, i)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		elem, ok := m.elems[i.ID]
+		if !ok {
+			elem = &fakeMenuElem{isItem: true}
+			m.elems[i.ID] = elem
+		}
+		elem.item = i
+	})
+}
+
+func (b *fakeBackend) MenuInsert(ptr unsafe.Pointer, parentID string, childID string, index int) {
+	b.record("MenuInsert", ptr, parentID, childID, index)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		parent, ok := m.elems[parentID]
+		if !ok {
+			return
+		}
+		if _, ok = m.elems[childID]; !ok {
+			return
+		}
+
+		if prevParent, ok := m.elems[m.parent[childID]]; ok {
+			prevParent.children = removeString(prevParent.children, childID)
+		}
+
+		if index < 0 || index > len(parent.children) {
+			index = len(parent.children)
+		}
+		parent.children = append(parent.children, "")
+		copy(parent.children[index+1:], parent.children[index:])
+		parent.children[index] = childID
+		m.parent[childID] = parentID
+	})
+}
+
+func (b *fakeBackend) MenuRemove(ptr unsafe.Pointer, parentID string, childID string) {
+	b.record("MenuRemove", ptr, parentID, childID)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		if parent, ok := m.elems[parentID]; ok {
+			parent.children = removeString(parent.children, childID)
+		}
+		m.dismount(childID)
+	})
+}
+
+func removeString(s []string, v string) []string {
+	for i, e := range s {
+		if e == v {
+			return append(s[:i:i], s[i+1:]...)
+		}
+	}
+	return s
+}
+
+func (b *fakeBackend) MenuClear(ptr unsafe.Pointer) {
+	b.record("MenuClear", ptr)
+	b.withMenu(ptr, func(m *fakeMenu) {
+		m.root = ""
+		m.elems = make(map[string]*fakeMenuElem)
+		m.parent = make(map[string]string)
+	})
+}
+
+// withMenu enqueues fn. It calls fn right away while the menu is updated by
+// an onopen handler, like the native calls made on the main thread.
+func (b *fakeBackend) withMenu(ptr unsafe.Pointer, fn func(m *fakeMenu)) {
+	b.mutex.Lock()
+	if m, ok := b.menus[ptr]; ok && m.updating {
+		fn(m)
+		b.mutex.Unlock()
+		return
+	}
+	b.mutex.Unlock()
+
+	b.async(func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if m, ok := b.menus[ptr]; ok {
+			fn(m)
+		}
+	})
+}
+
+func (b *fakeBackend) setMenuUpdating(ptr unsafe.Pointer, updating bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if m, ok := b.menus[ptr]; ok {
+		m.updating = updating
+	}
+}
+
+func (m *fakeMenu) dismount(id string) {
+	elem, ok := m.elems[id]
+	if !ok {
+		return
+	}
+
+	for _, child := range elem.children {
+		m.dismount(child)
+	}
+	delete(m.elems, id)
+	delete(m.parent, id)
+}
+
+func (b *fakeBackend) menuWithID(ptr unsafe.Pointer) (id string, elems map[string]fakeMenuElem) {
+	b.mutex.Lock()
+	if m, ok := b.menus[ptr]; ok {
+		id = m.id
+	}
+	b.mutex.Unlock()
+
+	_, elems = b.menu(ptr)
+	return id, elems
+}
+
+// menu returns a copy of the elements mounted in the menu pointed by ptr.
+func (b *fakeBackend) menu(ptr unsafe.Pointer) (root string, elems map[string]fakeMenuElem) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	m, ok := b.menus[ptr]
+	if !ok {
+		return
+	}
+
+	root = m.root
+	elems = make(map[string]fakeMenuElem, len(m.elems))
+	for id, elem := range m.elems {
+		e := *elem
+		e.children = append([]string(nil), elem.children...)
+		elems[id] = e
+	}
+	return
+}
+
+func (b *fakeBackend) NewFilePicker(p filePickerSpec) {
+	b.record("NewFilePicker", p)
+
+	b.mutex.Lock()
+	b.pickers[p.ID] = p
+	b.mutex.Unlock()
+}
+
+func (b *fakeBackend) OpenURL(rawurl string) {
+	b.record("OpenURL", rawurl)
+}
+
+func (b *fakeBackend) ShareText(v string) {
+	b.record("ShareText", v)
+}
+
+func (b *fakeBackend) ShareURL(v string) {
+	b.record("ShareURL", v)
+}
+
+func (b *fakeBackend) ResourcesDir() string {
+	return "resources"
+}
+
+func (b *fakeBackend) HomeDir() string {
+	return os.TempDir()
+}
+
+func (b *fakeBackend) SupportDir() string {
+	return os.TempDir()
+}
+
+func (b *fakeBackend) BundleID() string {
+	return ""
+}
+
+func (b *fakeBackend) BundleName() string {
+	return ""
+}
+
+func (b *fakeBackend) IsSandboxed() bool {
+	return false
+}
+
+// The following methods synthesize the events that Cocoa would report.
+
+func (b *fakeBackend) focus(ptr unsafe.Pointer) {
+	b.mutex.Lock()
+	prev := b.keyWindow
+	b.keyWindow = ptr
+	prevWin, hasPrev := b.windows[prev]
+	win, ok := b.windows[ptr]
+	b.mutex.Unlock()
+
+	if prev == ptr {
+		return
+	}
+	if hasPrev {
+		handleWindowBlur(uuid.FromStringOrNil(prevWin.spec.ID))
+	}
+	if ok {
+		handleWindowFocus(uuid.FromStringOrNil(win.spec.ID))
+	}
+}
+
+func (b *fakeBackend) emitWindowEvent(ptr unsafe.Pointer, handler func(id uuid.UUID)) {
+	b.async(func() {
+		if win, ok := b.window(ptr); ok {
+			handler(uuid.FromStringOrNil(win.spec.ID))
+		}
+	})
+}
+
+func (b *fakeBackend) focusWindow(ptr unsafe.Pointer) {
+	b.async(func() { b.focus(ptr) })
+}
+
+func (b *fakeBackend) minimizeWindow(ptr unsafe.Pointer) {
+	b.withWindow(ptr, func(win *fakeWindow) { win.minimized = true })
+	b.emitWindowEvent(ptr, handleWindowMinimize)
+}
+
+func (b *fakeBackend) deminimizeWindow(ptr unsafe.Pointer) {
+	b.withWindow(ptr, func(win *fakeWindow) { win.minimized = false })
+	b.emitWindowEvent(ptr, handleWindowDeminimize)
+}
+
+func (b *fakeBackend) enterFullScreen(ptr unsafe.Pointer) {
+	b.withWindow(ptr, func(win *fakeWindow) { win.fullScreen = true })
+	b.emitWindowEvent(ptr, handleWindowFullScreen)
+}
+
+func (b *fakeBackend) exitFullScreen(ptr unsafe.Pointer) {
+	b.withWindow(ptr, func(win *fakeWindow) { win.fullScreen = false })
+	b.emitWindowEvent(ptr, handleWindowExitFullScreen)
+}
+
+func (b *fakeBackend) reload(ptr unsafe.Pointer) {
+	b.emitWindowEvent(ptr, handleWindowWebviewLoaded)
+}
+
+func (b *fakeBackend) navigate(ptr unsafe.Pointer, rawurl string, navType NavigationType) {
+	b.emitWindowEvent(ptr, func(id uuid.UUID) {
+		handleWindowWebviewNavigate(id, rawurl, navType)
+	})
+}
+
+func (b *fakeBackend) postMessage(msg string) {
+	b.async(func() { handleJSCall(msg) })
+}
+
+func (b *fakeBackend) clickMenuItem(ptr unsafe.Pointer, itemID string) {
+	b.async(func() {
+		_, elems := b.menu(ptr)
+		elem, ok := elems[itemID]
+		if !ok || !elem.isItem || elem.item.Disabled {
+			return
+		}
+		b.mutex.Lock()
+		menuID := b.menus[ptr].id
+		b.mutex.Unlock()
+
+		handleMenuItemClick(uuid.FromStringOrNil(menuID), uuid.FromStringOrNil(itemID), elem.item.OnClick)
+	})
+}
+
+// openMenu synthesizes the opening of the container identified by id on the
+// queue goroutine. It blocks until the onopen handler returns, like the
+// native menu, and returns the children displayed in the container.
+func (b *fakeBackend) openMenu(ptr unsafe.Pointer, id string) (displayed []fakeMenuElem) {
+	done := make(chan struct{})
+
+	b.async(func() {
+		defer close(done)
+
+		menuID, elems := b.menuWithID(ptr)
+		if elem, ok := elems[id]; ok && !elem.isItem && len(elem.container.OnOpen) != 0 {
+			b.setMenuUpdating(ptr, true)
+			handleMenuOpen(uuid.FromStringOrNil(menuID), uuid.FromStringOrNil(id))
+			b.setMenuUpdating(ptr, false)
+		}
+
+		_, elems = b.menu(ptr)
+		for _, child := range elems[id].children {
+			displayed = append(displayed, elems[child])
+		}
+	})
+
+	<-done
+	return displayed
+}
+
+func (b *fakeBackend) closeMenu(ptr unsafe.Pointer) {
+	b.async(func() {
+		b.mutex.Lock()
+		m, ok := b.menus[ptr]
+		var onClose bool
+		if ok {
+			m.shown = false
+			root, isMounted := m.elems[m.root]
+			onClose = isMounted && len(root.container.OnClose) != 0
+		}
+		b.mutex.Unlock()
+
+		if !ok {
+			return
+		}
+
+		if onClose {
+			handleMenuClose(uuid.FromStringOrNil(m.id), uuid.FromStringOrNil(m.root))
+		}
+		handleMenuCloseFinal(uuid.FromStringOrNil(m.id))
+	})
+}
+
+func (b *fakeBackend) pickFiles(id string, filenames []string) {
+	b.async(func() {
+		b.mutex.Lock()
+		delete(b.pickers, id)
+		b.mutex.Unlock()
+
+		handleFilePickerClosed(uuid.FromStringOrNil(id), filenames)
+	})
+}
+
+func (b *fakeBackend) activate() {
+	b.async(handleFocus)
+}
+
+func (b *fakeBackend) deactivate() {
+	b.async(handleBlur)
+}
+
+func (b *fakeBackend) reopen() {
+	b.async(handleReopen)
+}
+
+func (b *fakeBackend) openFiles(filenames []string) {
+	b.async(func() { handleFilesOpen(filenames) })
+}
+
+func (b *fakeBackend) openURL(rawurl string) {
+	b.async(func() { handleURLOpen(rawurl) })
+}
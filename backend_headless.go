@@ -0,0 +1,38 @@
+package mac
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// headlessBackend is the backend used when Cocoa is not available.
+// It keeps the native state in memory without displaying anything, and does
+// not record calls. Window documents are held by the mounted components.
+type headlessBackend struct {
+	*fakeBackend
+}
+
+func newHeadlessBackend() *headlessBackend {
+	b := &headlessBackend{
+		fakeBackend: newFakeBackend(),
+	}
+	b.recording = false
+	return b
+}
+
+// HomeDir returns the home directory of the user, or the temporary directory
+// when it is unknown, as in stripped CI environments.
+func (b *headlessBackend) HomeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	return home
+}
+
+func (b *headlessBackend) SupportDir() string {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return dataHome
+	}
+	return filepath.Join(b.HomeDir(), ".local", "share")
+}
@@ -0,0 +1,38 @@
+package mac
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+func TestHeadlessBackend(t *testing.T) {
+	prev := native
+	native = newHeadlessBackend()
+	defer func() { native = prev }()
+
+	win := newTestWindow(t, app.Window{})
+	win.Mount(&WindowComponent{Greeting: "Maxoo"})
+
+	if html := win.HTML(); len(html) == 0 {
+		t.Error("window should have a document")
+	}
+	if calls := native.(*headlessBackend).Calls("WindowNew"); len(calls) != 0 {
+		t.Error("headless backend should not record calls")
+	}
+}
+
+func TestHeadlessBackendSupportDir(t *testing.T) {
+	b := newHeadlessBackend()
+
+	if dir := b.SupportDir(); !filepath.IsAbs(dir) {
+		t.Error("support dir should be an absolute path:", dir)
+	}
+
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	if dir := b.SupportDir(); !filepath.IsAbs(dir) {
+		t.Error("support dir should be an absolute path without HOME:", dir)
+	}
+}
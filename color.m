@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "color.h"
 
 @implementation CIColor (MBCategory)
@@ -0,0 +1,124 @@
+package mac
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
+
+// consoleJS hooks the webview console, the uncaught exceptions and the
+// unhandled promise rejections, and posts them to the Console message handler.
+const consoleJS = `(function () {
+  function format(args) {
+    return Array.prototype.map.call(args, function (arg) {
+      if (typeof arg === 'string') {
+        return arg;
+      }
+      if (arg instanceof Error) {
+        return arg.stack ? arg.toString() + '\n' + arg.stack : arg.toString();
+      }
+      try {
+        return JSON.stringify(arg);
+      } catch (e) {
+        return String(arg);
+      }
+    }).join(' ');
+  }
+
+  function callerLocation() {
+    var frames = (new Error().stack || '').split('\n');
+    var match = /(?:.*@)?(.+):(\d+):(\d+)$/.exec(frames[2] || '');
+    if (!match) {
+      return {};
+    }
+    return {source: match[1], line: +match[2], column: +match[3]};
+  }
+
+  function post(entry) {
+    try {
+      window.webkit.messageHandlers.Console.postMessage(JSON.stringify(entry));
+    } catch (e) {}
+  }
+
+  ['debug', 'log', 'info', 'warn', 'error'].forEach(function (level) {
+    var original = console[level];
+
+    console[level] = function () {
+      var location = callerLocation();
+      post({
+        level: level,
+        message: format(arguments),
+        source: location.source,
+        line: location.line,
+        column: location.column
+      });
+
+      if (original) {
+        original.apply(console, arguments);
+      }
+    };
+  });
+
+  window.addEventListener('error', function (e) {
+    post({
+      level: 'error',
+      message: 'uncaught ' + (e.error ? format([e.error]) : e.message),
+      source: e.filename,
+      line: e.lineno,
+      column: e.colno
+    });
+  });
+
+  window.addEventListener('unhandledrejection', function (e) {
+    post({
+      level: 'error',
+      message: 'unhandled promise rejection: ' + format([e.reason])
+    });
+  });
+})();
+`
+
+// consoleLoggers maps the console levels to the functions that log their
+// messages. The other levels, such as log, are logged as info.
+var consoleLoggers = map[string]func(format string, v ...interface{}){
+	"debug": log.Debugf,
+	"info":  log.Infof,
+	"warn":  log.Warnf,
+	"error": log.Errorf,
+}
+
+// consoleEntry is a message posted by consoleJS.
+type consoleEntry struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Source  string `json:"source"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func (e consoleEntry) location() string {
+	if len(e.Source) == 0 {
+		return "unknown location"
+	}
+	return fmt.Sprintf("%v:%v:%v", e.Source, e.Line, e.Column)
+}
+
+func handleJSConsole(windowID uuid.UUID, payload string) {
+	var entry consoleEntry
+	if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+		log.Error(errors.Wrap(err, "onJSConsole failed"))
+		return
+	}
+
+	app.UIChan <- func() {
+		logf, ok := consoleLoggers[entry.Level]
+		if !ok {
+			logf = consoleLoggers["info"]
+		}
+		logf("window %v: %v (%v)", windowID, entry.Message, entry.location())
+	}
+}
@@ -0,0 +1,68 @@
+package mac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/murlokswarm/app"
+	"github.com/satori/go.uuid"
+)
+
+func TestHandleJSConsole(t *testing.T) {
+	var logged []string
+
+	loggers := consoleLoggers
+	consoleLoggers = make(map[string]func(string, ...interface{}))
+	for _, level := range []string{"debug", "info", "warn", "error"} {
+		level := level
+		consoleLoggers[level] = func(format string, v ...interface{}) {
+			logged = append(logged, level+" "+fmt.Sprintf(format, v...))
+		}
+	}
+	defer func() { consoleLoggers = loggers }()
+
+	id := uuid.NewV1()
+
+	handleJSConsole(id, `{"level":"debug","message":"debug"}`)
+	handleJSConsole(id, `{"level":"log","message":"hello","source":"main.js","line":42,"column":21}`)
+	handleJSConsole(id, `{"level":"warn","message":"warning"}`)
+	handleJSConsole(id, `{"level":"error","message":"uncaught TypeError"}`)
+	handleJSConsole(id, `not json`)
+	waitUI()
+
+	expected := []string{
+		fmt.Sprintf("debug window %v: debug (unknown location)", id),
+		fmt.Sprintf("info window %v: hello (main.js:42:21)", id),
+		fmt.Sprintf("warn window %v: warning (unknown location)", id),
+		fmt.Sprintf("error window %v: uncaught TypeError (unknown location)", id),
+	}
+	if len(logged) != len(expected) {
+		t.Fatalf("%v entries should be logged: %q", len(expected), logged)
+	}
+	for i, e := range expected {
+		if logged[i] != e {
+			t.Errorf("entry %v should be %q: %q", i, e, logged[i])
+		}
+	}
+}
+
+func TestConsoleEntryLocation(t *testing.T) {
+	e := consoleEntry{Source: "main.js", Line: 42, Column: 21}
+	if loc := e.location(); loc != "main.js:42:21" {
+		t.Errorf("location should be main.js:42:21: %v", loc)
+	}
+}
+
+func TestWindowConsoleScript(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	nwin, ok := fakeNative().window(win.ptr)
+	if !ok {
+		t.Fatal("native window not found")
+	}
+	if !strings.Contains(nwin.spec.UserScript, "messageHandlers.Console") {
+		t.Error("window should hook the console")
+	}
+}
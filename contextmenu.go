@@ -1,10 +1,9 @@
 package mac
 
-/*
-#include "menu.h"
-*/
-import "C"
-import "github.com/murlokswarm/app"
+import (
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+)
 
 type contextMenu struct {
 	*menu
@@ -22,6 +21,18 @@ func (m *contextMenu) Close() error {
 }
 
 func (m *contextMenu) Mount(c app.Componer) {
-	m.menu.Mount(c)
-	C.Menu_Show(m.ptr)
+	if err := m.MountE(c); err != nil {
+		log.Panic(err)
+	}
+}
+
+// MountE mounts c in the context menu and shows it. It returns the markup
+// error instead of panicking.
+func (m *contextMenu) MountE(c app.Componer) error {
+	if err := m.menu.MountE(c); err != nil {
+		return err
+	}
+
+	native.MenuShow(m.ptr)
+	return nil
 }
@@ -0,0 +1,172 @@
+package mac
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/murlokswarm/markup"
+)
+
+// bindingRegexp matches the values of the event handlers that are bound to a
+// component, such as "OnClick" or "Form.OnSubmit".
+var bindingRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// strictCSPMinOS is the first macOS version whose WebKit honors the
+// script-src-elem and script-src-attr directives (Safari 15.4). Older versions
+// fall back to script-src, which blocks the event handler attributes the
+// markup is rendered with.
+var strictCSPMinOS = osVersion{Major: 12, Minor: 3}
+
+// osVersion is a version of macOS.
+type osVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func (v osVersion) less(o osVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+func (v osVersion) String() string {
+	return fmt.Sprintf("%v.%v.%v", v.Major, v.Minor, v.Patch)
+}
+
+// strictCSP returns a Content-Security-Policy that only allows the script
+// elements served with scheme.
+//
+// It is weaker for event handler attributes: script-src-attr 'unsafe-inline'
+// lets every on* attribute run, since the markup is rendered with them.
+// validateMarkup and validateSync ensure the attributes rendered by the
+// driver are component bindings, but the attributes that scripts add to the
+// DOM are not checked.
+func strictCSP(scheme string) string {
+	src := scheme + ":"
+
+	return strings.Join([]string{
+		"default-src " + src,
+		"script-src " + src,
+		"script-src-elem " + src,
+		"script-src-attr 'unsafe-inline'",
+		"style-src " + src + " 'unsafe-inline'",
+		"img-src " + src + " data: blob:",
+		"font-src " + src + " data:",
+		"object-src 'none'",
+		"base-uri 'none'",
+		"form-action 'none'",
+	}, "; ")
+}
+
+// contentSecurityPolicy returns the policy of the document of a window
+// configured with w.
+func contentSecurityPolicy(w Window) string {
+	if len(w.CSP) != 0 || !w.StrictCSP {
+		return w.CSP
+	}
+	return strictCSP(driver.ResourceScheme)
+}
+
+// checkStrictCSP returns an *UnsupportedOSError when a window configured with
+// w would get a generated policy that the running macOS does not support.
+func checkStrictCSP(w Window) error {
+	if !w.StrictCSP || len(w.CSP) != 0 {
+		return nil
+	}
+
+	if v := native.OSVersion(); v.less(strictCSPMinOS) {
+		return &UnsupportedOSError{
+			Feature:  "StrictCSP",
+			Required: strictCSPMinOS.String(),
+			Current:  v.String(),
+		}
+	}
+	return nil
+}
+
+// customizeHead adds the Content-Security-Policy and the head elements of a
+// window configured with w to the document described by doc.
+// The policy is placed at the top of the head since it only applies to the
+// elements that follow it.
+func customizeHead(doc string, w Window) string {
+	var top string
+	if csp := contentSecurityPolicy(w); len(csp) != 0 {
+		top = fmt.Sprintf(`<meta http-equiv="Content-Security-Policy" content="%v">`, html.EscapeString(csp))
+	}
+	bottom := strings.Join(w.Head, "\n")
+
+	lower := strings.ToLower(doc)
+
+	start := strings.Index(lower, "<head")
+	if start == -1 {
+		return top + bottom + doc
+	}
+	start += strings.Index(lower[start:], ">") + 1
+
+	end := strings.Index(lower, "</head>")
+	if end < start {
+		end = start
+	}
+	return doc[:start] + top + doc[start:end] + bottom + doc[end:]
+}
+
+// validateMarkup returns an *InlineHandlerError when n or one of its
+// descendants has an event handler that is not bound to a component.
+func validateMarkup(n *markup.Node) error {
+	if n == nil {
+		return nil
+	}
+
+	if n.Type == markup.ComponentNode {
+		return validateMarkup(markup.Root(n.Component))
+	}
+
+	if n.Type == markup.HTMLNode {
+		if err := validateAttributes(n.Tag, n.Attributes); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range n.Children {
+		if err := validateMarkup(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSync returns an *InlineHandlerError when the nodes or the attributes
+// updated by s have an event handler that is not bound to a component.
+func validateSync(s markup.Sync) error {
+	if s.Scope == markup.AttrSync {
+		return validateAttributes(s.Node.Tag, s.Attributes)
+	}
+	return validateMarkup(s.Node)
+}
+
+func validateAttributes(tag string, attrs markup.AttributeMap) error {
+	for name, value := range attrs {
+		if !isEventHandler(name) || bindingRegexp.MatchString(value) {
+			continue
+		}
+
+		return &InlineHandlerError{
+			Tag:   tag,
+			Name:  name,
+			Value: value,
+		}
+	}
+	return nil
+}
+
+func isEventHandler(attr string) bool {
+	attr = strings.ToLower(strings.TrimPrefix(attr, "_"))
+	return strings.HasPrefix(attr, "on")
+}
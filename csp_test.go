@@ -0,0 +1,139 @@
+package mac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
+)
+
+func TestCustomizeHead(t *testing.T) {
+	doc := `<html><head><meta charset="utf-8"><title>test</title></head><body></body></html>`
+
+	html := customizeHead(doc, Window{
+		Head: []string{`<meta name="color-scheme" content="light dark">`},
+		CSP:  "default-src 'self'",
+	})
+
+	csp := `<meta http-equiv="Content-Security-Policy" content="default-src &#39;self&#39;">`
+	if !strings.HasPrefix(html, `<html><head>`+csp+`<meta charset="utf-8">`) {
+		t.Errorf("policy should be at the top of the head: %v", html)
+	}
+	if !strings.Contains(html, `<title>test</title><meta name="color-scheme" content="light dark"></head>`) {
+		t.Errorf("head elements should be at the bottom of the head: %v", html)
+	}
+
+	if html = customizeHead(doc, Window{}); html != doc {
+		t.Errorf("document should not be changed: %v", html)
+	}
+}
+
+func TestContentSecurityPolicy(t *testing.T) {
+	if csp := contentSecurityPolicy(Window{}); len(csp) != 0 {
+		t.Errorf("policy should be empty: %v", csp)
+	}
+
+	csp := contentSecurityPolicy(Window{StrictCSP: true})
+	directives := make(map[string]string)
+	for _, d := range strings.Split(csp, "; ") {
+		parts := strings.SplitN(d, " ", 2)
+		directives[parts[0]] = parts[1]
+	}
+
+	src := driver.ResourceScheme + ":"
+	for _, name := range []string{"script-src", "script-src-elem"} {
+		if v := directives[name]; v != src {
+			t.Errorf("%v should restrict scripts to the resources scheme: %q", name, v)
+		}
+	}
+
+	// Known weakening: the markup is rendered with event handler attributes,
+	// so any on* attribute runs, including the ones added by scripts.
+	if v := directives["script-src-attr"]; v != "'unsafe-inline'" {
+		t.Errorf("script-src-attr should allow the event handler attributes: %q", v)
+	}
+
+	if csp = contentSecurityPolicy(Window{CSP: "default-src 'none'", StrictCSP: true}); csp != "default-src 'none'" {
+		t.Errorf("policy should be the custom one: %v", csp)
+	}
+}
+
+func TestValidateMarkup(t *testing.T) {
+	list, items := newTestList(2)
+	items[0].Attributes = markup.AttributeMap{"onclick": "OnSelect"}
+	items[1].Attributes = markup.AttributeMap{"onchange": "Form.OnChange"}
+
+	if err := validateMarkup(list); err != nil {
+		t.Fatal(err)
+	}
+
+	items[1].Attributes["onmouseover"] = "alert(document.cookie)"
+
+	err := validateMarkup(list)
+	if _, ok := err.(*InlineHandlerError); !ok {
+		t.Fatalf("error should be an *InlineHandlerError: %v", err)
+	}
+
+	if err = validateSync(attrSync(items[0], "ONCLICK", "fetch('//evil')")); err == nil {
+		t.Error("attribute sync should be rejected")
+	}
+	if err = validateSync(attrSync(items[0], "title", "alert(1)")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWindowStrictCSP(t *testing.T) {
+	win, err := newWindow(Window{
+		Head:      []string{`<link rel="preload" href="css/main.css" as="style">`},
+		StrictCSP: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fakeNative().Calls("WindowNew")
+	spec := calls[len(calls)-1].Args[0].(windowSpec)
+	if !strings.HasSuffix(spec.UserScript, app.MurlokJS()) {
+		t.Error("murlok js should be injected with the user script")
+	}
+
+	node := &markup.Node{
+		ID:         uuid.NewV1(),
+		Tag:        "button",
+		Attributes: markup.AttributeMap{"onclick": "alert(1)"},
+	}
+
+	app.UIChan <- func() {
+		win.Render(markup.Sync{Scope: markup.FullSync, Node: node})
+	}
+	waitUI()
+	fakeNative().flush()
+
+	if n := len(fakeNative().scripts(win.ptr)); n != 0 {
+		t.Errorf("sync should be dropped: %v scripts", n)
+	}
+}
+
+func TestStrictCSPOSVersion(t *testing.T) {
+	defer fakeNative().setOSVersion(fakeNative().OSVersion())
+	fakeNative().setOSVersion(osVersion{Major: 11, Minor: 6})
+
+	_, err := newWindow(Window{StrictCSP: true})
+	if _, ok := err.(*UnsupportedOSError); !ok {
+		t.Errorf("err should be an *UnsupportedOSError: %v", err)
+	}
+
+	win, err := newWindow(Window{StrictCSP: true, CSP: "default-src 'self'"})
+	if err != nil {
+		t.Fatal("a custom policy should be allowed:", err)
+	}
+	win.Close()
+
+	fakeNative().setOSVersion(osVersion{Major: 12, Minor: 3})
+	if win, err = newWindow(Window{StrictCSP: true}); err != nil {
+		t.Fatal(err)
+	}
+	win.Close()
+}
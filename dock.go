@@ -1,16 +1,10 @@
 package mac
 
-/*
-#include "driver.h"
-*/
-import "C"
 import (
 	"fmt"
 	"os"
-	"unsafe"
 
 	"github.com/murlokswarm/app"
-	"github.com/murlokswarm/errors"
 	"github.com/murlokswarm/log"
 )
 
@@ -25,9 +19,23 @@ func newDock() *dock {
 }
 
 func (d *dock) Mount(c app.Componer) {
-	driver.mustRun()
-	d.menu.Mount(c)
-	C.Driver_SetDockMenu(d.ptr)
+	if err := d.MountE(c); err != nil {
+		log.Panic(err)
+	}
+}
+
+// MountE mounts c in the dock menu. It returns the markup error instead of
+// panicking.
+func (d *dock) MountE(c app.Componer) error {
+	if err := driver.checkRunning(); err != nil {
+		return err
+	}
+	if err := d.menu.MountE(c); err != nil {
+		return err
+	}
+
+	native.SetDockMenu(d.ptr)
+	return nil
 }
 
 func (d *dock) Component() app.Componer {
@@ -37,25 +45,36 @@ func (d *dock) Component() app.Componer {
 func (d *dock) SetIcon(path string) {
 	driver.mustRun()
 
-	cpath := C.CString(path)
-	defer free(unsafe.Pointer(cpath))
+	if err := d.SetIconE(path); err != nil {
+		log.Error(err)
+	}
+}
+
+// SetIconE sets the dock icon. It returns an *UnsupportedIconError or a
+// *MissingIconError when path does not point to a valid icon.
+func (d *dock) SetIconE(path string) error {
+	if err := driver.checkRunning(); err != nil {
+		return err
+	}
 
 	if len(path) == 0 {
-		C.Driver_SetDockIcon(cpath)
-		return
+		native.SetDockIcon(path)
+		return nil
 	}
 
 	if !app.FileIsSupportedIcon(path) {
-		log.Error(errors.Newf("extension of %v is not supported", path))
-		return
+		return &UnsupportedIconError{Path: path}
 	}
 
 	if _, err := os.Stat(path); err != nil {
-		log.Error(errors.New(err))
-		return
+		return &MissingIconError{
+			Path: path,
+			Err:  err,
+		}
 	}
 
-	C.Driver_SetDockIcon(cpath)
+	native.SetDockIcon(path)
+	return nil
 }
 
 func (d *dock) SetBadge(v interface{}) {
@@ -64,7 +83,5 @@ func (d *dock) SetBadge(v interface{}) {
 	if v == nil {
 		v = ""
 	}
-	cv := C.CString(fmt.Sprint(v))
-	defer free(unsafe.Pointer(cv))
-	C.Driver_SetDockBadge(cv)
+	native.SetDockBadge(fmt.Sprint(v))
 }
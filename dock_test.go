@@ -7,8 +7,8 @@ func TestNewDock(t *testing.T) {
 }
 
 func TestDockMount(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	d := newDock()
 	c := &MenuComponent{}
@@ -17,8 +17,8 @@ func TestDockMount(t *testing.T) {
 }
 
 func TestDockSetIcon(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	d := newDock()
 
@@ -35,9 +35,30 @@ func TestDockSetIcon(t *testing.T) {
 	d.SetIcon("resources/logosh.png")
 }
 
+func TestDockSetIconE(t *testing.T) {
+	d := newDock()
+
+	if _, ok := d.SetIconE("resources/logo.png").(*NotRunningError); !ok {
+		t.Error("err should be a *NotRunningError")
+	}
+
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
+
+	if err := d.SetIconE("resources/logo.png"); err != nil {
+		t.Error(err)
+	}
+	if _, ok := d.SetIconE("resources/logo.bmp").(*UnsupportedIconError); !ok {
+		t.Error("err should be an *UnsupportedIconError")
+	}
+	if _, ok := d.SetIconE("resources/logosh.png").(*MissingIconError); !ok {
+		t.Error("err should be a *MissingIconError")
+	}
+}
+
 func TestDockSetBadge(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	d := newDock()
 	d.SetBadge(42)
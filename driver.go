@@ -5,18 +5,13 @@
 // Driver implementation.
 package mac
 
-/*
-#cgo CFLAGS: -x objective-c -fobjc-arc
-#cgo LDFLAGS: -framework Cocoa
-#cgo LDFLAGS: -framework WebKit
-#cgo LDFLAGS: -framework CoreImage
-#cgo LDFLAGS: -framework Security
-#include "driver.h"
-*/
-import "C"
 import (
-	"encoding/json"
+	"io/fs"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/murlokswarm/app"
 	"github.com/murlokswarm/log"
@@ -25,55 +20,177 @@ import (
 
 var (
 	driver *Driver
+	native backend
 )
 
+const (
+	defaultWindowTimeout = time.Second * 10
+	defaultJSTimeout     = time.Second * 10
+
+	defaultMenuOpenTimeout = time.Millisecond * 500
+)
+
+// postUI queues fn on the UI goroutine. It does not block, which makes it
+// usable from the UI goroutine itself.
+func postUI(fn func()) {
+	select {
+	case app.UIChan <- fn:
+	default:
+		go func() { app.UIChan <- fn }()
+	}
+}
+
 func init() {
+	native = newBackend()
 	driver = NewDriver()
 	app.RegisterDriver(driver)
 }
 
 // Driver is the implementation of the MacOS driver.
 type Driver struct {
-	appMenu app.Contexter
-	dock    app.Docker
-	running bool
+	// WindowTimeout is the maximum duration to wait for a window to be created
+	// and for its webview to be loaded.
+	WindowTimeout time.Duration
+
+	// JSTimeout is the maximum duration to wait for the result of a javascript
+	// evaluation.
+	JSTimeout time.Duration
+
+	// MenuOpenTimeout is the maximum duration a menu waits for its onopen
+	// handler before being displayed. The menu does not respond meanwhile.
+	MenuOpenTimeout time.Duration
+
+	// ExternalSchemes are the URL schemes that the default navigation policy
+	// opens with the default app of the user.
+	ExternalSchemes []string
+
+	// ResourcesFS is the filesystem that holds the resources served to the
+	// webviews, such as an embedded filesystem. The css and js directories
+	// are included in the pages. It defaults to the resources directory.
+	ResourcesFS fs.FS
+
+	// ResourceScheme is the URL scheme the resources are served with.
+	ResourceScheme string
+
+	// LiveReload enables a development mode that watches the css and js files
+	// of the resources. Stylesheets are replaced in the open windows when a
+	// css file changes. The windows are reloaded with their component when a
+	// js file changes. It is off by default and must be set before Run.
+	LiveReload bool
+
+	// LiveReloadInterval is the interval at which the resources are checked
+	// for changes. Changes are applied once the resources have been unchanged
+	// for an interval. It defaults to 300ms.
+	LiveReloadInterval time.Duration
+
+	// Router builds the components targeted by navigations and by the URLs
+	// the app is asked to open.
+	Router *Router
+
+	// RouteURLOpen reports whether an URL the app is asked to open is mounted
+	// in the focused window through the Router. It is called on the UI
+	// goroutine before app.OnURLOpen. URLs are left to app.OnURLOpen and the
+	// subscribers when it is nil.
+	RouteURLOpen func(URL url.URL) bool
+
+	appMenu          app.Contexter
+	dock             app.Docker
+	stateMutex       sync.Mutex
+	state            State
+	stateEvents      chan StateEvent
+	termination      *termination
+	subscribersMutex sync.Mutex
+	subscribers      []*subscriber
+	windowsMutex     sync.Mutex
+	windows          []*window
+	focusedWindow    *window
 }
 
 // NewDriver creates a new MacOS driver.
 // It initializes the Cocoa app.
 func NewDriver() *Driver {
-	return &Driver{
-		appMenu: newMenuBar(),
-		dock:    newDock(),
+	d := &Driver{
+		WindowTimeout:   defaultWindowTimeout,
+		JSTimeout:       defaultJSTimeout,
+		MenuOpenTimeout: defaultMenuOpenTimeout,
+		ExternalSchemes: []string{
+			"http",
+			"https",
+			"mailto",
+		},
+		ResourceScheme: defaultResourceScheme,
+		Router:         newRouter(),
+		appMenu:        newMenuBar(),
+		dock:           newDock(),
+		stateEvents:    make(chan StateEvent, 16),
 	}
+
+	go d.forwardStateEvents()
+	return d
+}
+
+// ErrorMounter is implemented by the elements that can report mount errors
+// instead of panicking.
+type ErrorMounter interface {
+	MountE(c app.Componer) error
+}
+
+// CurrentDriver returns the driver registered by the package.
+func CurrentDriver() *Driver {
+	return driver
 }
 
 // Run launches the Cocoa app.
 func (d *Driver) Run() {
-	d.running = true
-	C.Driver_Run()
+	d.setState(StateLaunching)
+	native.Run()
 }
 
 // NewElement creates a new app element.
+// It panics if the element can't be created. Use NewElementE to get an error
+// instead.
 func (d *Driver) NewElement(e interface{}) app.Elementer {
-	driver.mustRun()
+	elem, err := d.NewElementE(e)
+	if err != nil {
+		log.Panic(err)
+	}
+	return elem
+}
+
+// NewElementE creates a new app element.
+// It returns an *UnsupportedElementError when e does not describe an element
+// handled by the driver, and a *NotRunningError when the app is not running.
+func (d *Driver) NewElementE(e interface{}) (app.Elementer, error) {
+	if err := d.checkRunning(); err != nil {
+		return nil, err
+	}
 
 	switch elem := e.(type) {
 	case app.Window:
-		return newWindow(elem)
+		win, err := newWindow(Window{Window: elem})
+		if err != nil {
+			return nil, err
+		}
+		return win, nil
+
+	case Window:
+		win, err := newWindow(elem)
+		if err != nil {
+			return nil, err
+		}
+		return win, nil
 
 	case app.ContextMenu:
-		return newContextMenu(elem)
+		return newContextMenu(elem), nil
 
 	case app.Share:
-		return newShare(elem)
+		return newShare(elem), nil
 
 	case app.FilePicker:
-		return newFilePicker(elem)
+		return newFilePicker(elem), nil
 
 	default:
-		log.Panicf("element described by %T is not implemented", elem)
-		return nil
+		return nil, &UnsupportedElementError{Element: elem}
 	}
 }
 
@@ -92,6 +209,15 @@ func (d *Driver) Resources() string {
 	return resources()
 }
 
+// AppName returns the name of the app bundle. It returns the name of the
+// executable when the app is not packaged.
+func (d *Driver) AppName() string {
+	if name := native.BundleName(); len(name) != 0 {
+		return name
+	}
+	return filepath.Base(os.Args[0])
+}
+
 // Storage returns the location of the app storage directory.
 func (d *Driver) Storage() string {
 	return storage()
@@ -104,102 +230,149 @@ func (d *Driver) JavascriptBridge() string {
 }
 
 func (d *Driver) terminate() {
-	C.Driver_Terminate()
+	native.Terminate()
 }
 
 func (d *Driver) mustRun() {
-	if !d.running {
-		log.Panic(`app is not running`)
+	if err := d.checkRunning(); err != nil {
+		log.Panic(err)
 	}
 }
 
-//export onLaunch
-func onLaunch() {
+func (d *Driver) checkRunning() error {
+	switch d.State() {
+	case StateLaunching, StateRunning, StateTerminating:
+		return nil
+
+	default:
+		return &NotRunningError{}
+	}
+}
+
+func handleLaunch() {
+	driver.setState(StateRunning)
+
+	if driver.LiveReload {
+		go driver.watchResources()
+	}
+
 	app.UIChan <- func() {
 		if app.OnLaunch != nil {
 			app.OnLaunch()
 		}
+		driver.emit(LaunchEvent{})
 	}
 }
 
-//export onFocus
-func onFocus() {
+func handleFocus() {
 	app.UIChan <- func() {
 		if app.OnFocus != nil {
 			app.OnFocus()
 		}
+		driver.emit(FocusEvent{})
 	}
 }
 
-//export onBlur
-func onBlur() {
+func handleBlur() {
 	app.UIChan <- func() {
 		if app.OnBlur != nil {
 			app.OnBlur()
 		}
+		driver.emit(BlurEvent{})
 	}
 }
 
-//export onReopen
-func onReopen() {
+func handleReopen() {
 	app.UIChan <- func() {
 		if app.OnReopen != nil {
 			app.OnReopen()
 		}
+		driver.emit(ReopenEvent{})
 	}
 }
 
-//export onFilesOpen
-func onFilesOpen(cfilenamesJSON *C.char) {
-	filenamesJSON := C.GoString(cfilenamesJSON)
-
-	var filenames []string
-	if err := json.Unmarshal([]byte(filenamesJSON), &filenames); err != nil {
-		log.Error(err)
-		return
-	}
-
+func handleFilesOpen(filenames []string) {
 	app.UIChan <- func() {
 		if app.OnFilesOpen != nil {
 			app.OnFilesOpen(filenames)
 		}
+		driver.emit(FilesOpenEvent{Filenames: filenames})
 	}
 }
 
-//export onURLOpen
-func onURLOpen(curl *C.char) {
-	urlString := C.GoString(curl)
-	URL, err := url.Parse(urlString)
+func handleURLOpen(rawurl string) {
+	URL, err := url.Parse(rawurl)
 	if err != nil {
 		log.Error(errors.Wrap(err, "onURLOpen failed"))
 		return
 	}
 
 	app.UIChan <- func() {
+		routeURLOpen(URL)
+
 		if app.OnURLOpen != nil {
 			app.OnURLOpen(*URL)
 		}
+		driver.emit(URLOpenEvent{URL: *URL})
 	}
 }
 
-//export onTerminate
-func onTerminate() bool {
+func handleTerminate() bool {
+	prev := driver.setState(StateTerminating)
 	termChan := make(chan bool)
 
 	app.UIChan <- func() {
+		terminate := true
 		if app.OnTerminate != nil {
-			termChan <- app.OnTerminate()
-			return
+			terminate = app.OnTerminate()
 		}
 
-		termChan <- true
+		e := &TerminateEvent{}
+		driver.emit(e)
+		termChan <- terminate && !e.Vetoed()
+	}
+
+	terminate := <-termChan
+	if !terminate {
+		driver.setState(prev)
+		driver.endTermination(true)
 	}
-	return <-termChan
+	return terminate
 }
 
-//export onFinalize
-func onFinalize() {
+// finalizeTimeout is the maximum duration the exit waits for the UI goroutine
+// to emit the FinalizeEvent.
+var finalizeTimeout = time.Second * 2
+
+func handleFinalize() {
 	if app.OnFinalize != nil {
 		app.OnFinalize()
 	}
+
+	for _, w := range driver.Windows() {
+		w.(*window).flushFrame()
+	}
+
+	// The UI goroutine can be busy or stopped during the teardown.
+	emitted := make(chan struct{})
+	emit := func() {
+		driver.emit(FinalizeEvent{})
+		close(emitted)
+	}
+	timeout := time.After(finalizeTimeout)
+
+	select {
+	case app.UIChan <- emit:
+		select {
+		case <-emitted:
+		case <-timeout:
+			log.Warnf("FinalizeEvent was not emitted within %v", finalizeTimeout)
+		}
+
+	case <-timeout:
+		log.Warnf("FinalizeEvent was not emitted within %v", finalizeTimeout)
+	}
+
+	driver.setState(StateTerminated)
+	driver.endTermination(false)
 }
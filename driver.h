@@ -7,6 +7,15 @@
   dispatch_async(dispatch_get_main_queue(), ^{                                 \
                      code})
 
+// onMain runs code right away when called from the main thread, which is the
+// case when Go answers a Cocoa callback, and defers it otherwise.
+#define onMain(code)                                                           \
+  if ([NSThread isMainThread]) {                                               \
+    code                                                                       \
+  } else {                                                                     \
+    defer(code);                                                               \
+  }
+
 @interface DriverDelegate : NSObject <NSApplicationDelegate>
 @property NSMenu *dock;
 
@@ -15,6 +24,7 @@
 
 void Driver_Run();
 void Driver_Terminate();
+void Driver_OSVersion(int *major, int *minor, int *patch);
 void Driver_SetMenuBar(const void *menuPtr);
 void Driver_SetDockMenu(const void *dockPtr);
 void Driver_SetDockIcon(const char *path);
@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "driver.h"
 #include "_cgo_export.h"
 #include "menu.h"
@@ -80,10 +83,18 @@ void Driver_Run() {
 
 void Driver_Terminate() { defer([NSApp terminate:NSApp];); }
 
+void Driver_OSVersion(int *major, int *minor, int *patch) {
+  NSOperatingSystemVersion v = [NSProcessInfo processInfo].operatingSystemVersion;
+  *major = (int)v.majorVersion;
+  *minor = (int)v.minorVersion;
+  *patch = (int)v.patchVersion;
+}
+
 void Driver_SetMenuBar(const void *menuPtr) {
   Menu *menu = (__bridge Menu *)menuPtr;
 
-  defer(NSApp.mainMenu = menu.Root; [NSApp activateIgnoringOtherApps:YES];);
+  defer(NSApp.mainMenu = menu.Root; [menu registerRoles];
+        [NSApp activateIgnoringOtherApps:YES];);
 }
 
 void Driver_SetDockMenu(const void *dockPtr) {
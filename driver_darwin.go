@@ -0,0 +1,115 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#cgo CFLAGS: -x objective-c -fobjc-arc
+#cgo LDFLAGS: -framework Cocoa
+#cgo LDFLAGS: -framework WebKit
+#cgo LDFLAGS: -framework CoreImage
+#cgo LDFLAGS: -framework Security
+#include "driver.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/murlokswarm/log"
+)
+
+// cocoaBackend is the backend implementation that calls Cocoa.
+type cocoaBackend struct{}
+
+func newBackend() backend {
+	return cocoaBackend{}
+}
+
+func (b cocoaBackend) Run() {
+	C.Driver_Run()
+}
+
+func (b cocoaBackend) Terminate() {
+	C.Driver_Terminate()
+}
+
+func (b cocoaBackend) OSVersion() osVersion {
+	var major, minor, patch C.int
+	C.Driver_OSVersion(&major, &minor, &patch)
+
+	return osVersion{
+		Major: int(major),
+		Minor: int(minor),
+		Patch: int(patch),
+	}
+}
+
+func (b cocoaBackend) SetMenuBar(menu unsafe.Pointer) {
+	C.Driver_SetMenuBar(menu)
+}
+
+func (b cocoaBackend) SetDockMenu(menu unsafe.Pointer) {
+	C.Driver_SetDockMenu(menu)
+}
+
+func (b cocoaBackend) SetDockIcon(path string) {
+	cpath := cString(path)
+	defer free(unsafe.Pointer(cpath))
+
+	C.Driver_SetDockIcon(cpath)
+}
+
+func (b cocoaBackend) SetDockBadge(badge string) {
+	cbadge := cString(badge)
+	defer free(unsafe.Pointer(cbadge))
+
+	C.Driver_SetDockBadge(cbadge)
+}
+
+//export onLaunch
+func onLaunch() {
+	handleLaunch()
+}
+
+//export onFocus
+func onFocus() {
+	handleFocus()
+}
+
+//export onBlur
+func onBlur() {
+	handleBlur()
+}
+
+//export onReopen
+func onReopen() {
+	handleReopen()
+}
+
+//export onFilesOpen
+func onFilesOpen(cfilenamesJSON *C.char) {
+	filenamesJSON := C.GoString(cfilenamesJSON)
+
+	var filenames []string
+	if err := json.Unmarshal([]byte(filenamesJSON), &filenames); err != nil {
+		log.Error(err)
+		return
+	}
+	handleFilesOpen(filenames)
+}
+
+//export onURLOpen
+func onURLOpen(curl *C.char) {
+	handleURLOpen(C.GoString(curl))
+}
+
+//export onTerminate
+func onTerminate() bool {
+	return handleTerminate()
+}
+
+//export onFinalize
+func onFinalize() {
+	handleFinalize()
+}
@@ -0,0 +1,10 @@
+//go:build !darwin || headless
+// +build !darwin headless
+
+package mac
+
+// newBackend returns the headless backend on platforms where Cocoa is not
+// available or when the headless build tag is set.
+func newBackend() backend {
+	return newHeadlessBackend()
+}
@@ -2,12 +2,33 @@ package mac
 
 import (
 	"net/url"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/murlokswarm/app"
 )
 
+func TestMain(m *testing.M) {
+	native = newFakeBackend()
+	driver = NewDriver()
+	os.Exit(m.Run())
+}
+
+func fakeNative() *fakeBackend {
+	return native.(*fakeBackend)
+}
+
+// waitUI waits for the functions queued on the UI goroutine to be executed.
+func waitUI() {
+	done := make(chan struct{})
+	app.UIChan <- func() {
+		close(done)
+	}
+	<-done
+}
+
 func TestDriver(t *testing.T) {
 	t.Log(driver.MenuBar())
 	t.Log(driver.Dock())
@@ -17,11 +38,11 @@ func TestDriver(t *testing.T) {
 }
 
 func TestDriverNewElement(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
-	// Window => would bloc.
-	// driver.Element(app.Window{})
+	// Window.
+	driver.NewElement(app.Window{})
 
 	// Menu.
 	driver.NewElement(app.ContextMenu{})
@@ -30,12 +51,33 @@ func TestDriverNewElement(t *testing.T) {
 func TestDriverNewElementNotImplemented(t *testing.T) {
 	defer func() { recover() }()
 
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	driver.NewElement("not implement")
 }
 
+func TestDriverNewElementE(t *testing.T) {
+	if _, err := driver.NewElementE(app.ContextMenu{}); err == nil {
+		t.Error("err should not be nil")
+	} else if _, ok := err.(*NotRunningError); !ok {
+		t.Errorf("err should be a *NotRunningError: %T", err)
+	}
+
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
+
+	if _, err := driver.NewElementE("not implement"); err == nil {
+		t.Error("err should not be nil")
+	} else if _, ok := err.(*UnsupportedElementError); !ok {
+		t.Errorf("err should be an *UnsupportedElementError: %T", err)
+	}
+
+	if _, err := driver.NewElementE(app.ContextMenu{}); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestDriverNewElementPanic(t *testing.T) {
 	defer func() { recover() }()
 
@@ -44,35 +86,41 @@ func TestDriverNewElementPanic(t *testing.T) {
 }
 
 func TestOnLaunch(t *testing.T) {
+	defer driver.setState(StateIdle)
+
 	app.OnLaunch = func() {
 		t.Log("MacOS driver onLaunch")
 	}
-	onLaunch()
+	handleLaunch()
 }
 
 func TestFocused(t *testing.T) {
 	app.OnFocus = func() {
 		t.Log("MacOS driver onFocus")
 	}
-	onFocus()
+	handleFocus()
 }
 
 func TestOnBlur(t *testing.T) {
 	app.OnBlur = func() {
 		t.Log("MacOS driver onBlur")
 	}
-	onBlur()
+	handleBlur()
 }
 
 func TestOnReopen(t *testing.T) {
-	app.OnReopen = func(v bool) {
-		t.Log("MacOS driver onReopen:", v)
+	reopened := false
+	app.OnReopen = func() {
+		reopened = true
+	}
+	defer func() { app.OnReopen = nil }()
 
-		if !v {
-			t.Error("v should be true")
-		}
+	handleReopen()
+	waitUI()
+
+	if !reopened {
+		t.Error("OnReopen should have been called")
 	}
-	onReopen(true)
 }
 
 func TestOnFilesOpen(t *testing.T) {
@@ -85,7 +133,7 @@ func TestOnFilesOpen(t *testing.T) {
 			t.Error("filenames[1] should be mune")
 		}
 	}
-	onFilesOpen(cString(`["zune", "mune"]`))
+	handleFilesOpen([]string{"zune", "mune"})
 }
 
 func TestOnURLOpen(t *testing.T) {
@@ -96,25 +144,28 @@ func TestOnURLOpen(t *testing.T) {
 		t.Log("MacOS driver onURLOpen:", URL)
 		wg.Done()
 	}
-	onURLOpen(cString("github-mac://openRepo/https://github.com/murlokswarm/app"))
+	defer func() { app.OnURLOpen = nil }()
+	handleURLOpen("github-mac://openRepo/https://github.com/murlokswarm/app")
 
 	wg.Wait()
 
 }
 
 func TestOnTerminate(t *testing.T) {
+	defer driver.setState(StateIdle)
+
 	app.OnTerminate = func() bool {
 		t.Log("MacOS driver onTerminate")
 		return false
 	}
 
-	if ret := onTerminate(); ret {
+	if ret := handleTerminate(); ret {
 		t.Error("ret should be false")
 	}
 
 	app.OnTerminate = nil
 
-	if ret := onTerminate(); !ret {
+	if ret := handleTerminate(); !ret {
 		t.Error("ret should be true")
 	}
 }
@@ -123,5 +174,29 @@ func TestOnFinalize(t *testing.T) {
 	app.OnFinalize = func() {
 		t.Log("MacOS driver onFinalize")
 	}
-	onFinalize()
+	handleFinalize()
+}
+
+func TestFinalizeBusyUI(t *testing.T) {
+	defer func(d time.Duration) { finalizeTimeout = d }(finalizeTimeout)
+	finalizeTimeout = time.Millisecond * 10
+
+	release := make(chan struct{})
+	app.UIChan <- func() { <-release }
+
+	finalized := make(chan struct{})
+	go func() {
+		handleFinalize()
+		close(finalized)
+	}()
+
+	select {
+	case <-finalized:
+	case <-time.After(time.Second):
+		t.Fatal("finalize should not wait for a busy UI goroutine")
+	}
+
+	// The late FinalizeEvent must not reach the next tests.
+	close(release)
+	waitUI()
 }
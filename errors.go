@@ -0,0 +1,115 @@
+package mac
+
+import "fmt"
+
+// UnsupportedElementError is returned when an element description is not
+// supported by the driver.
+type UnsupportedElementError struct {
+	Element interface{}
+}
+
+func (e *UnsupportedElementError) Error() string {
+	return fmt.Sprintf("element described by %T is not implemented", e.Element)
+}
+
+// NotRunningError is returned when an operation requires the app to be
+// running.
+type NotRunningError struct{}
+
+func (e *NotRunningError) Error() string {
+	return "app is not running"
+}
+
+// MenuMarkupError is returned when the markup of a component mounted in a
+// menu context is invalid.
+type MenuMarkupError struct {
+	Node   string
+	Reason string
+}
+
+func (e *MenuMarkupError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Node, e.Reason)
+}
+
+// MissingIconError is returned when an icon file does not exist.
+type MissingIconError struct {
+	Path string
+	Err  error
+}
+
+func (e *MissingIconError) Error() string {
+	return fmt.Sprintf("icon %v is missing: %v", e.Path, e.Err)
+}
+
+// UnsupportedIconError is returned when the extension of an icon file is not
+// supported.
+type UnsupportedIconError struct {
+	Path string
+}
+
+func (e *UnsupportedIconError) Error() string {
+	return fmt.Sprintf("extension of %v is not supported", e.Path)
+}
+
+// ShortcutError is returned when a keyboard shortcut can't be parsed.
+type ShortcutError struct {
+	Shortcut string
+	Reason   string
+}
+
+func (e *ShortcutError) Error() string {
+	return fmt.Sprintf("shortcut %q is invalid: %v", e.Shortcut, e.Reason)
+}
+
+// RouteNotFoundError is returned when an URL does not target any component.
+type RouteNotFoundError struct {
+	URL string
+}
+
+func (e *RouteNotFoundError) Error() string {
+	return fmt.Sprintf("no component is routed for %v", e.URL)
+}
+
+// MissingResourceError is returned when a resource requested by a webview is
+// not in the resources filesystem.
+type MissingResourceError struct {
+	Name string
+}
+
+func (e *MissingResourceError) Error() string {
+	return fmt.Sprintf("resource %v is missing from the resources filesystem", e.Name)
+}
+
+// InlineHandlerError is returned when markup mounted in a window with a strict
+// Content-Security-Policy has an event handler that is not bound to a
+// component.
+type InlineHandlerError struct {
+	Tag   string
+	Name  string
+	Value string
+}
+
+func (e *InlineHandlerError) Error() string {
+	return fmt.Sprintf("%v: %v=%q is not bound to a component", e.Tag, e.Name, e.Value)
+}
+
+// UnsupportedOSError is returned when a feature requires a more recent
+// version of macOS.
+type UnsupportedOSError struct {
+	Feature  string
+	Required string
+	Current  string
+}
+
+func (e *UnsupportedOSError) Error() string {
+	return fmt.Sprintf("%v requires macOS %v or later: running %v", e.Feature, e.Required, e.Current)
+}
+
+// JSError is returned when a javascript evaluation throws an exception.
+type JSError struct {
+	Message string
+}
+
+func (e *JSError) Error() string {
+	return fmt.Sprintf("javascript exception: %v", e.Message)
+}
@@ -0,0 +1,90 @@
+package mac
+
+import "net/url"
+
+// Event is an application event delivered to the driver subscribers.
+// It is one of LaunchEvent, FocusEvent, BlurEvent, ReopenEvent,
+// FilesOpenEvent, URLOpenEvent, *TerminateEvent, FinalizeEvent or StateEvent.
+type Event interface{}
+
+// LaunchEvent is emitted when the app has finished launching.
+type LaunchEvent struct{}
+
+// FocusEvent is emitted when the app becomes active.
+type FocusEvent struct{}
+
+// BlurEvent is emitted when the app stops being active.
+type BlurEvent struct{}
+
+// ReopenEvent is emitted when the app is reopened, for example by clicking
+// its dock icon.
+type ReopenEvent struct{}
+
+// FilesOpenEvent is emitted when the app is asked to open files.
+type FilesOpenEvent struct {
+	Filenames []string
+}
+
+// URLOpenEvent is emitted when the app is asked to open an URL.
+type URLOpenEvent struct {
+	URL url.URL
+}
+
+// TerminateEvent is emitted when the app is asked to terminate.
+// Any subscriber can prevent the termination by calling Veto.
+type TerminateEvent struct {
+	vetoed bool
+}
+
+// Veto cancels the termination.
+func (e *TerminateEvent) Veto() {
+	e.vetoed = true
+}
+
+// Vetoed reports whether a subscriber has cancelled the termination.
+func (e *TerminateEvent) Vetoed() bool {
+	return e.vetoed
+}
+
+// FinalizeEvent is emitted right before the app exits.
+type FinalizeEvent struct{}
+
+type subscriber struct {
+	handler func(Event)
+}
+
+// Subscribe registers handler to be called on the UI goroutine with each
+// application event. Events are also forwarded to the app.On* functions.
+// It returns a function that cancels the subscription.
+func (d *Driver) Subscribe(handler func(Event)) (unsubscribe func()) {
+	s := &subscriber{
+		handler: handler,
+	}
+
+	d.subscribersMutex.Lock()
+	d.subscribers = append(d.subscribers, s)
+	d.subscribersMutex.Unlock()
+
+	return func() {
+		d.subscribersMutex.Lock()
+		defer d.subscribersMutex.Unlock()
+
+		for i, sub := range d.subscribers {
+			if sub == s {
+				d.subscribers = append(d.subscribers[:i:i], d.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit delivers e to the subscribers. It must be called on the UI goroutine.
+func (d *Driver) emit(e Event) {
+	d.subscribersMutex.Lock()
+	subscribers := d.subscribers
+	d.subscribersMutex.Unlock()
+
+	for _, s := range subscribers {
+		s.handler(e)
+	}
+}
@@ -0,0 +1,106 @@
+package mac
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+func TestDriverSubscribe(t *testing.T) {
+	defer driver.setState(StateIdle)
+
+	events := make(chan Event, 16)
+	unsubscribe := driver.Subscribe(func(e Event) {
+		if _, ok := e.(StateEvent); !ok {
+			events <- e
+		}
+	})
+
+	handleLaunch()
+	if _, ok := (<-events).(LaunchEvent); !ok {
+		t.Error("event should be a LaunchEvent")
+	}
+
+	handleFilesOpen([]string{"zune", "mune"})
+	if e, ok := (<-events).(FilesOpenEvent); !ok {
+		t.Error("event should be a FilesOpenEvent")
+	} else if len(e.Filenames) != 2 {
+		t.Error("event should have 2 filenames:", e.Filenames)
+	}
+
+	handleURLOpen("github-mac://openRepo/https://github.com/murlokswarm/app")
+	if e, ok := (<-events).(URLOpenEvent); !ok {
+		t.Error("event should be an URLOpenEvent")
+	} else if e.URL.Scheme != "github-mac" {
+		t.Error("event URL scheme should be github-mac:", e.URL.Scheme)
+	}
+
+	unsubscribe()
+	handleFocus()
+
+	done := make(chan struct{})
+	app.UIChan <- func() { close(done) }
+	<-done
+
+	if len(events) != 0 {
+		t.Error("unsubscribed handler should not receive events")
+	}
+}
+
+func TestDriverSubscribeMultiple(t *testing.T) {
+	var urls []url.URL
+
+	unsubscribeA := driver.Subscribe(func(e Event) {
+		if e, ok := e.(URLOpenEvent); ok {
+			urls = append(urls, e.URL)
+		}
+	})
+	defer unsubscribeA()
+
+	unsubscribeB := driver.Subscribe(func(e Event) {
+		if e, ok := e.(URLOpenEvent); ok {
+			urls = append(urls, e.URL)
+		}
+	})
+	defer unsubscribeB()
+
+	handleURLOpen("murlok://hello")
+
+	done := make(chan struct{})
+	app.UIChan <- func() { close(done) }
+	<-done
+
+	if len(urls) != 2 {
+		t.Error("both subscribers should have received the event:", len(urls))
+	}
+}
+
+func TestDriverSubscribeTerminateVeto(t *testing.T) {
+	defer driver.setState(StateIdle)
+
+	app.OnTerminate = nil
+
+	unsubscribeA := driver.Subscribe(func(e Event) {})
+	defer unsubscribeA()
+
+	if !handleTerminate() {
+		t.Error("termination should not be vetoed")
+	}
+
+	unsubscribeB := driver.Subscribe(func(e Event) {
+		if e, ok := e.(*TerminateEvent); ok {
+			e.Veto()
+		}
+	})
+
+	if handleTerminate() {
+		t.Error("termination should be vetoed")
+	}
+
+	unsubscribeB()
+
+	if !handleTerminate() {
+		t.Error("termination should not be vetoed")
+	}
+}
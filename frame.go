@@ -0,0 +1,104 @@
+package mac
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+)
+
+// frameSaveDelay is the time a frame has to stay unchanged before it is
+// saved. It prevents writing the frame at every event of a drag or a live
+// resize.
+const frameSaveDelay = time.Millisecond * 500
+
+// WindowFrame is the geometry and the state of a window saved under a frame
+// key.
+type WindowFrame struct {
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	FullScreen bool    `json:"fullscreen"`
+	Minimized  bool    `json:"minimized"`
+}
+
+// SavedWindowFrame returns the frame saved under key. Frames that can't be
+// read are logged and reported as not saved.
+func (d *Driver) SavedWindowFrame(key string) (f WindowFrame, ok bool) {
+	f, err := loadWindowFrame(key)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error(errors.Wrapf(err, "reading frame %v failed", key))
+		}
+		return WindowFrame{}, false
+	}
+	return f, true
+}
+
+// ResetWindowFrame deletes the frame saved under key. The windows that use
+// key open with their default geometry from then on.
+func (d *Driver) ResetWindowFrame(key string) error {
+	err := os.Remove(windowFramePath(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func windowFramePath(key string) string {
+	return filepath.Join(storage(), "frames", url.PathEscape(key)+".json")
+}
+
+func loadWindowFrame(key string) (f WindowFrame, err error) {
+	data, err := ioutil.ReadFile(windowFramePath(key))
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &f)
+	return
+}
+
+func saveWindowFrame(key string, f WindowFrame) error {
+	name := windowFramePath(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return errors.Wrapf(err, "saving frame %v failed", key)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return errors.Wrapf(err, "saving frame %v failed", key)
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+// clampWindowFrame moves and shrinks f to fit in the screen it overlaps the
+// most, or in the first screen when it overlaps none.
+func clampWindowFrame(f WindowFrame, screens []screenFrame) WindowFrame {
+	if len(screens) == 0 {
+		return f
+	}
+
+	screen := screens[0]
+	maxArea := 0.0
+	for _, s := range screens {
+		w := math.Min(f.X+f.Width, s.X+s.Width) - math.Max(f.X, s.X)
+		h := math.Min(f.Y+f.Height, s.Y+s.Height) - math.Max(f.Y, s.Y)
+		if w > 0 && h > 0 && w*h > maxArea {
+			screen = s
+			maxArea = w * h
+		}
+	}
+
+	f.Width = math.Min(f.Width, screen.Width)
+	f.Height = math.Min(f.Height, screen.Height)
+	f.X = math.Max(screen.X, math.Min(f.X, screen.X+screen.Width-f.Width))
+	f.Y = math.Max(screen.Y, math.Min(f.Y, screen.Y+screen.Height-f.Height))
+	return f
+}
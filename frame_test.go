@@ -0,0 +1,169 @@
+package mac
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/murlokswarm/app"
+	"github.com/satori/go.uuid"
+)
+
+func TestClampWindowFrame(t *testing.T) {
+	screens := []screenFrame{
+		{Width: 1440, Height: 900},
+		{X: 1440, Width: 1920, Height: 1080},
+	}
+
+	tests := []struct {
+		scenario string
+		frame    WindowFrame
+		expected WindowFrame
+	}{
+		{
+			scenario: "frame in first screen",
+			frame:    WindowFrame{X: 100, Y: 100, Width: 800, Height: 600},
+			expected: WindowFrame{X: 100, Y: 100, Width: 800, Height: 600},
+		},
+		{
+			scenario: "frame mostly in second screen",
+			frame:    WindowFrame{X: 1400, Y: 100, Width: 800, Height: 600},
+			expected: WindowFrame{X: 1440, Y: 100, Width: 800, Height: 600},
+		},
+		{
+			scenario: "frame outside screens",
+			frame:    WindowFrame{X: -5000, Y: 3000, Width: 800, Height: 600},
+			expected: WindowFrame{X: 0, Y: 300, Width: 800, Height: 600},
+		},
+		{
+			scenario: "frame bigger than screen",
+			frame:    WindowFrame{X: 10, Y: 10, Width: 2000, Height: 1000},
+			expected: WindowFrame{X: 0, Y: 0, Width: 1440, Height: 900},
+		},
+	}
+
+	for _, test := range tests {
+		if f := clampWindowFrame(test.frame, screens); f != test.expected {
+			t.Errorf("%v: frame should be %+v: %+v", test.scenario, test.expected, f)
+		}
+	}
+}
+
+func TestWindowFramePersistence(t *testing.T) {
+	key := "test-" + uuid.NewV1().String()
+	defer driver.ResetWindowFrame(key)
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+
+	win, err := newWindow(Window{
+		Window: app.Window{
+			Width:    800,
+			Height:   600,
+			OnMove:   func(x, y float64) { wg.Done() },
+			OnResize: func(width, height float64) { wg.Done() },
+			OnMinimize: func() {
+				wg.Done()
+			},
+		},
+		FrameKey: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	win.Move(42, 21)
+	win.Resize(1024, 768)
+	fakeNative().minimizeWindow(win.ptr)
+	wg.Wait()
+	win.flushFrame()
+	waitUI()
+
+	expected := WindowFrame{X: 42, Y: 21, Width: 1024, Height: 768, Minimized: true}
+	if f, ok := driver.SavedWindowFrame(key); !ok || f != expected {
+		t.Fatalf("saved frame should be %+v: %+v", expected, f)
+	}
+
+	restored, err := newWindow(Window{
+		Window:   app.Window{Width: 800, Height: 600},
+		FrameKey: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeNative().flush()
+	waitUI()
+
+	if x, y := restored.Position(); x != 42 || y != 21 {
+		t.Errorf("position should be 42, 21: %v, %v", x, y)
+	}
+	if w, h := restored.Size(); w != 1024 || h != 768 {
+		t.Errorf("size should be 1024, 768: %v, %v", w, h)
+	}
+	if len(fakeNative().Calls("WindowMinimize")) == 0 {
+		t.Error("restored window should be minimized")
+	}
+
+	if err := driver.ResetWindowFrame(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := driver.SavedWindowFrame(key); ok {
+		t.Error("frame should be reset")
+	}
+}
+
+func TestWindowFrameSaveDelay(t *testing.T) {
+	key := "test-" + uuid.NewV1().String()
+	defer driver.ResetWindowFrame(key)
+
+	moved := make(chan struct{}, 20)
+
+	win, err := newWindow(Window{
+		Window: app.Window{
+			Width:  800,
+			Height: 600,
+			OnMove: func(x, y float64) { moved <- struct{}{} },
+		},
+		FrameKey: key,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		win.Move(float64(i), 0)
+		<-moved
+	}
+	waitUI()
+
+	if _, ok := driver.SavedWindowFrame(key); ok {
+		t.Fatal("frame should not be saved during a drag")
+	}
+
+	time.Sleep(frameSaveDelay * 2)
+	waitUI()
+
+	if f, ok := driver.SavedWindowFrame(key); !ok || f.X != 19 {
+		t.Errorf("frame should be saved with the last position: %+v", f)
+	}
+}
+
+func TestSavedWindowFrameInvalid(t *testing.T) {
+	key := "test-" + uuid.NewV1().String()
+	defer driver.ResetWindowFrame(key)
+
+	name := windowFramePath(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := driver.SavedWindowFrame(key); ok {
+		t.Error("an invalid frame should not be reported as saved")
+	}
+}
@@ -0,0 +1,220 @@
+package mac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
+
+const defaultHistorySize = 100
+
+// historyJS binds Cmd+[ and Cmd+] to the window history.
+const historyJS = `(function () {
+  document.addEventListener('keydown', function (e) {
+    if (!e.metaKey || e.altKey || e.ctrlKey || e.shiftKey) {
+      return;
+    }
+
+    var direction = {'[': 'back', ']': 'forward'}[e.key];
+    if (!direction) {
+      return;
+    }
+
+    e.preventDefault();
+    window.webkit.messageHandlers.History.postMessage(direction);
+  });
+})();
+`
+
+// historyEntry is a page of the window history: the URL the component was
+// mounted for and the scroll position of the page.
+type historyEntry struct {
+	URL     *url.URL
+	scrollX float64
+	scrollY float64
+}
+
+// componentURL returns the URL that targets the component type of c. It
+// names the history entries of the components mounted without an URL.
+func componentURL(c app.Componer) *url.URL {
+	t := reflect.TypeOf(c)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return &url.URL{
+		Scheme: "component",
+		Host:   t.Name(),
+	}
+}
+
+// pushHistory records URL as the current entry of the window history. The
+// entries after the current one are dropped, and the oldest ones are dropped
+// when the history is full.
+func (w *window) pushHistory(URL *url.URL) {
+	size := w.config.HistorySize
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	w.historyMutex.Lock()
+	if len(w.history) != 0 {
+		w.history = w.history[:w.historyIndex+1]
+	}
+	w.history = append(w.history, &historyEntry{URL: URL})
+	if over := len(w.history) - size; over > 0 {
+		w.history = append([]*historyEntry(nil), w.history[over:]...)
+	}
+	w.historyIndex = len(w.history) - 1
+	w.historyMutex.Unlock()
+
+	w.historyChanged()
+}
+
+// historyChanged queues the call of OnHistoryChange on the UI goroutine.
+func (w *window) historyChanged() {
+	if w.config.OnHistoryChange != nil {
+		postUI(w.config.OnHistoryChange)
+	}
+}
+
+// History returns the URLs of the window history and the index of the current
+// one.
+func (w *window) History() (entries []*url.URL, current int) {
+	w.historyMutex.Lock()
+	defer w.historyMutex.Unlock()
+
+	for _, e := range w.history {
+		entries = append(entries, e.URL)
+	}
+	return entries, w.historyIndex
+}
+
+// saveScroll records the scroll position of the page in the current history
+// entry before another component is mounted.
+func (w *window) saveScroll() {
+	w.historyMutex.Lock()
+	if len(w.history) == 0 {
+		w.historyMutex.Unlock()
+		return
+	}
+	entry := w.history[w.historyIndex]
+	w.historyMutex.Unlock()
+
+	evalJS(w.id, w.ptr, "[window.scrollX, window.scrollY]", func(result json.RawMessage, err error) {
+		var scroll [2]float64
+		if err == nil {
+			err = json.Unmarshal(result, &scroll)
+		}
+		if err != nil {
+			log.Error(errors.Wrap(err, "saving scroll position failed"))
+			return
+		}
+
+		w.historyMutex.Lock()
+		entry.scrollX, entry.scrollY = scroll[0], scroll[1]
+		w.historyMutex.Unlock()
+	})
+}
+
+// CanGoBack reports whether the window history has an entry before the
+// current one.
+func (w *window) CanGoBack() bool {
+	w.historyMutex.Lock()
+	defer w.historyMutex.Unlock()
+
+	return w.historyIndex > 0
+}
+
+// CanGoForward reports whether the window history has an entry after the
+// current one.
+func (w *window) CanGoForward() bool {
+	w.historyMutex.Lock()
+	defer w.historyMutex.Unlock()
+
+	return w.historyIndex < len(w.history)-1
+}
+
+// Back mounts a new component for the URL of the previous history entry and
+// restores its scroll position. It must be called on the UI goroutine.
+func (w *window) Back() error {
+	return w.goHistory(-1)
+}
+
+// Forward mounts a new component for the URL of the next history entry and
+// restores its scroll position. It must be called on the UI goroutine.
+func (w *window) Forward() error {
+	return w.goHistory(1)
+}
+
+func handleWindowHistory(id uuid.UUID, direction string) {
+	ctx, ok := app.Elements().Get(id)
+	if !ok {
+		return
+	}
+	win := ctx.(*window)
+
+	app.UIChan <- func() {
+		var err error
+
+		switch direction {
+		case "back":
+			if win.CanGoBack() {
+				err = win.Back()
+			}
+
+		case "forward":
+			if win.CanGoForward() {
+				err = win.Forward()
+			}
+
+		default:
+			err = errors.Errorf("unknown history direction %q", direction)
+		}
+
+		if err != nil {
+			log.Error(errors.Wrapf(err, "window %v", id))
+		}
+	}
+}
+
+func (w *window) goHistory(delta int) error {
+	w.historyMutex.Lock()
+	index := w.historyIndex + delta
+	if index < 0 || index >= len(w.history) {
+		w.historyMutex.Unlock()
+		return errors.New("no history entry to go to")
+	}
+	entry := w.history[index]
+	w.historyMutex.Unlock()
+
+	c, err := driver.Router.Resolve(entry.URL)
+	if err != nil {
+		return errors.Wrapf(err, "going to %v failed", entry.URL)
+	}
+
+	w.saveScroll()
+	if err = w.mount(c); err != nil {
+		return errors.Wrapf(err, "going to %v failed", entry.URL)
+	}
+
+	if hrefer, ok := c.(app.Hrefer); ok {
+		hrefer.OnHref(entry.URL)
+	}
+
+	w.historyMutex.Lock()
+	w.historyIndex = index
+	script := fmt.Sprintf("window.scrollTo(%v, %v);", entry.scrollX, entry.scrollY)
+	w.historyMutex.Unlock()
+
+	evalJS(w.id, w.ptr, script, logJSError("restoring scroll position failed"))
+
+	w.historyChanged()
+	return nil
+}
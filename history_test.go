@@ -0,0 +1,167 @@
+package mac
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+func routeTestWindow(t *testing.T, win *window, rawurl string) {
+	URL, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := driver.Router.Resolve(URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = win.mountRouted(c, URL); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWindowHistory(t *testing.T) {
+	defer func(r *Router) { driver.Router = r }(driver.Router)
+	driver.Router = newRouter()
+	driver.Router.Handle("component://document/{id}", &RoutedDocument{})
+
+	changes := 0
+	win, err := newWindow(Window{
+		HistorySize: 3,
+		OnHistoryChange: func() {
+			changes++
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer win.Close()
+
+	if win.CanGoBack() || win.CanGoForward() {
+		t.Error("empty history should not be navigable")
+	}
+	if err = win.Back(); err == nil {
+		t.Error("going back in an empty history should fail")
+	}
+
+	for _, rawurl := range []string{
+		"component://document/1",
+		"component://document/2",
+		"component://document/3",
+		"component://document/4",
+	} {
+		routeTestWindow(t, win, rawurl)
+	}
+
+	entries, current := win.History()
+	if len(entries) != 3 || current != 2 || entries[0].String() != "component://document/2" {
+		t.Fatalf("history should keep the 3 last entries: %v %v", entries, current)
+	}
+
+	if err = win.Back(); err != nil {
+		t.Fatal(err)
+	}
+	if doc := win.Component().(*RoutedDocument); doc.ID != 3 {
+		t.Errorf("document 3 should be mounted: %v", doc.ID)
+	}
+	if !win.CanGoForward() {
+		t.Error("history should go forward")
+	}
+
+	if err = win.Forward(); err != nil {
+		t.Fatal(err)
+	}
+	if doc := win.Component().(*RoutedDocument); doc.ID != 4 {
+		t.Errorf("document 4 should be mounted: %v", doc.ID)
+	}
+
+	win.Back()
+	win.Back()
+	routeTestWindow(t, win, "component://document/5")
+
+	entries, current = win.History()
+	if len(entries) != 2 || current != 1 || entries[1].String() != "component://document/5" {
+		t.Errorf("forward entries should be dropped: %v %v", entries, current)
+	}
+	waitUI()
+	if changes != 9 {
+		t.Errorf("history should have changed 9 times: %v", changes)
+	}
+}
+
+// HistoryPage is a routed component that records the URL it is navigated
+// with.
+type HistoryPage struct {
+	ID   int
+	Tab  string
+	Note string
+	href *url.URL
+}
+
+func (p *HistoryPage) Render() string {
+	return `<div>page</div>`
+}
+
+func (p *HistoryPage) OnHref(u *url.URL) {
+	p.href = u
+}
+
+func TestWindowHistoryState(t *testing.T) {
+	defer func(r *Router) { driver.Router = r }(driver.Router)
+	driver.Router = newRouter()
+	driver.Router.Handle("component://page/{id}", &HistoryPage{})
+
+	defer fakeNative().setEvalJS(nil)
+	fakeNative().setEvalJS(func(js string) (json.RawMessage, string) {
+		if strings.Contains(js, "window.scrollY") {
+			return json.RawMessage("[0,120]"), ""
+		}
+		return json.RawMessage("null"), ""
+	})
+
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	routeTestWindow(t, win, "component://page/1?tab=info")
+	page := win.Component().(*HistoryPage)
+	page.Note = "edited"
+
+	routeTestWindow(t, win, "component://page/2")
+	fakeNative().flush()
+
+	entries, _ := win.History()
+	if entries[0].String() != "component://page/1?tab=info" {
+		t.Errorf("history should keep the query: %v", entries[0])
+	}
+
+	if err := win.Back(); err != nil {
+		t.Fatal(err)
+	}
+
+	back := win.Component().(*HistoryPage)
+	if back == page || back.Note != "" {
+		t.Errorf("a new component should be mounted: %+v", back)
+	}
+	if back.ID != 1 || back.Tab != "info" {
+		t.Errorf("component should be resolved from the entry URL: %+v", back)
+	}
+	if back.href == nil || back.href.String() != "component://page/1?tab=info" {
+		t.Errorf("component should receive the entry URL: %v", back.href)
+	}
+
+	fakeNative().flush()
+	scripts := fakeNative().scripts(win.ptr)
+	if last := scripts[len(scripts)-1]; last != "window.scrollTo(0, 120);" {
+		t.Errorf("scroll position should be restored: %v", last)
+	}
+}
+
+func TestComponentURL(t *testing.T) {
+	if u := componentURL(&RoutedDocument{}); u.String() != "component://RoutedDocument" {
+		t.Errorf("url should be component://RoutedDocument: %v", u)
+	}
+}
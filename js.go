@@ -1,25 +1,137 @@
 package mac
 
-import "C"
 import (
+	"encoding/json"
+	"sync"
+	"unsafe"
+
 	"github.com/murlokswarm/app"
 	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
+
+var (
+	jsCallsMutex sync.Mutex
+	jsCalls      = make(map[uuid.UUID]jsCall)
 )
 
-//export onJSCall
-func onJSCall(cmsg *C.char) {
-	msg := C.GoString(cmsg)
+// JSEvaluator is implemented by the elements that can evaluate javascript
+// and report its result.
+type JSEvaluator interface {
+	// EvalJS evaluates script and returns its result encoded in JSON.
+	// An exception thrown by script is returned as a *JSError.
+	EvalJS(script string) (json.RawMessage, error)
+
+	// EvalJSAsync evaluates script and calls callback on the UI goroutine
+	// with its result.
+	EvalJSAsync(script string, callback func(result json.RawMessage, err error))
+}
+
+// jsCall is a javascript evaluation waiting for its result.
+type jsCall struct {
+	windowID uuid.UUID
+	done     func(result json.RawMessage, err error)
+}
+
+// evalJS evaluates script in the window pointed by ptr. done is called with
+// the result from the goroutine that reports it.
+func evalJS(windowID uuid.UUID, ptr unsafe.Pointer, script string, done func(result json.RawMessage, err error)) uuid.UUID {
+	callID := uuid.NewV1()
+
+	jsCallsMutex.Lock()
+	jsCalls[callID] = jsCall{
+		windowID: windowID,
+		done:     done,
+	}
+	jsCallsMutex.Unlock()
+
+	native.WindowEvalJS(ptr, callID.String(), script)
+	return callID
+}
+
+func popJSCall(callID uuid.UUID) (call jsCall, ok bool) {
+	jsCallsMutex.Lock()
+	defer jsCallsMutex.Unlock()
+
+	if call, ok = jsCalls[callID]; ok {
+		delete(jsCalls, callID)
+	}
+	return
+}
+
+// cancelJSCalls ends the evaluations pending in the window identified by
+// windowID with err.
+func cancelJSCalls(windowID uuid.UUID, err error) {
+	var cancelled []jsCall
 
+	jsCallsMutex.Lock()
+	for id, call := range jsCalls {
+		if call.windowID == windowID {
+			cancelled = append(cancelled, call)
+			delete(jsCalls, id)
+		}
+	}
+	jsCallsMutex.Unlock()
+
+	for _, call := range cancelled {
+		call.done(nil, err)
+	}
+}
+
+func handleWindowJSResult(callID uuid.UUID, result json.RawMessage, exception string) {
+	call, ok := popJSCall(callID)
+	if !ok {
+		return
+	}
+
+	if len(exception) != 0 {
+		call.done(nil, &JSError{Message: exception})
+		return
+	}
+	call.done(result, nil)
+}
+
+// handleWindowJSResultJSON completes the call identified by callID with the
+// result reported by the webview, a JSON array that holds the value. A result
+// that can't be decoded completes the call with an error.
+func handleWindowJSResultJSON(callID uuid.UUID, resultJSON string, exception string) {
+	var result []json.RawMessage
+	err := json.Unmarshal([]byte(resultJSON), &result)
+
+	switch {
+	case len(exception) != 0:
+
+	case err != nil:
+		exception = "decoding the result failed: " + err.Error()
+
+	case len(result) == 0:
+		exception = "decoding the result failed: no value"
+	}
+
+	var value json.RawMessage
+	if len(result) != 0 {
+		value = result[0]
+	}
+	handleWindowJSResult(callID, value, exception)
+}
+
+func handleJSCall(msg string) {
 	app.UIChan <- func() {
 		app.HandleEvent(msg)
 	}
 }
 
-//export onJSAlert
-func onJSAlert(calert *C.char) {
-	alert := C.GoString(calert)
-
+func handleJSAlert(alert string) {
 	app.UIChan <- func() {
 		log.Warn(alert)
 	}
 }
+
+func logJSError(context string) func(json.RawMessage, error) {
+	return func(result json.RawMessage, err error) {
+		if err != nil {
+			log.Error(errors.Wrap(err, context))
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+import "C"
+
+//export onJSCall
+func onJSCall(cmsg *C.char) {
+	handleJSCall(C.GoString(cmsg))
+}
+
+//export onJSConsole
+func onJSConsole(cid *C.char, centry *C.char) {
+	handleJSConsole(goUUID(cid), C.GoString(centry))
+}
+
+//export onJSAlert
+func onJSAlert(calert *C.char) {
+	handleJSAlert(C.GoString(calert))
+}
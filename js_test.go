@@ -1,18 +1,132 @@
 package mac
 
-import "testing"
-import "unsafe"
+import (
+	"encoding/json"
+	"testing"
 
-func TestOnJSCall(t *testing.T) {
-	msg := cString("hello")
-	defer free(unsafe.Pointer(msg))
+	"github.com/murlokswarm/app"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
 
-	onJSCall(msg)
+func TestOnJSCall(t *testing.T) {
+	handleJSCall("hello")
 }
 
 func TestOnJSAlert(t *testing.T) {
-	alert := cString("alert")
-	defer free(unsafe.Pointer(alert))
+	handleJSAlert("alert")
+}
+
+func TestWindowEvalJS(t *testing.T) {
+	fakeNative().setEvalJS(func(js string) (json.RawMessage, string) {
+		if js == "throw 'boo'" {
+			return nil, "boo"
+		}
+		return json.RawMessage("42"), ""
+	})
+	defer fakeNative().setEvalJS(nil)
+
+	win := newTestWindow(t, app.Window{})
+
+	result, err := win.EvalJS("21 * 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "42" {
+		t.Errorf("result should be 42: %s", result)
+	}
+
+	_, err = win.EvalJS("throw 'boo'")
+	if jserr, ok := err.(*JSError); !ok || jserr.Message != "boo" {
+		t.Errorf("err should be a *JSError with message boo: %v", err)
+	}
+}
+
+func TestWindowEvalJSAsync(t *testing.T) {
+	fakeNative().setEvalJS(func(js string) (json.RawMessage, string) {
+		return json.RawMessage(`"hello"`), ""
+	})
+	defer fakeNative().setEvalJS(nil)
+
+	win := newTestWindow(t, app.Window{})
+	done := make(chan json.RawMessage)
+
+	win.EvalJSAsync(`"hello"`, func(result json.RawMessage, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		done <- result
+	})
+
+	if result := <-done; string(result) != `"hello"` {
+		t.Errorf(`result should be "hello": %s`, result)
+	}
+}
+
+func TestCancelJSCalls(t *testing.T) {
+	windowID := uuid.NewV1()
+	callID := uuid.NewV1()
+	cancelled := false
+
+	jsCallsMutex.Lock()
+	jsCalls[callID] = jsCall{
+		windowID: windowID,
+		done: func(result json.RawMessage, err error) {
+			cancelled = err != nil
+		},
+	}
+	jsCallsMutex.Unlock()
+
+	cancelJSCalls(windowID, errors.New("window closed"))
+	if !cancelled {
+		t.Error("pending call should be cancelled")
+	}
+	if _, ok := popJSCall(callID); ok {
+		t.Error("pending call should be removed")
+	}
+}
+
+func TestHandleWindowJSResultJSON(t *testing.T) {
+	tests := []struct {
+		resultJSON string
+		exception  string
+		result     string
+		err        bool
+	}{
+		{resultJSON: `["hello"]`, result: `"hello"`},
+		{resultJSON: `[null]`, exception: "ReferenceError", err: true},
+		{resultJSON: `{`, err: true},
+		{resultJSON: `[]`, err: true},
+	}
+
+	for _, test := range tests {
+		callID := uuid.NewV1()
+		done := make(chan struct{})
+
+		var result json.RawMessage
+		var err error
+
+		jsCallsMutex.Lock()
+		jsCalls[callID] = jsCall{
+			done: func(r json.RawMessage, e error) {
+				result, err = r, e
+				close(done)
+			},
+		}
+		jsCallsMutex.Unlock()
+
+		handleWindowJSResultJSON(callID, test.resultJSON, test.exception)
 
-	onJSAlert(alert)
+		select {
+		case <-done:
+		default:
+			t.Fatalf("%s: call should be completed", test.resultJSON)
+		}
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error: %v", test.resultJSON, err)
+		}
+		if !test.err && string(result) != test.result {
+			t.Errorf("%s: result should be %s: %s", test.resultJSON, test.result, result)
+		}
+	}
 }
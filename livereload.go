@@ -0,0 +1,249 @@
+package mac
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
+
+const defaultLiveReloadInterval = time.Millisecond * 300
+
+// reloadCSSJS replaces the stylesheets loaded from the css directory. The
+// previous stylesheets are removed once the new ones are loaded to avoid a
+// flash of unstyled content.
+const reloadCSSJS = `(function (files, version) {
+  var previous = Array.prototype.filter.call(
+    document.querySelectorAll('link[rel="stylesheet"]'),
+    function (link) {
+      return (link.getAttribute('href') || '').indexOf('css/') === 0;
+    }
+  );
+
+  var pending = files.length;
+  function loaded() {
+    if (--pending > 0) {
+      return;
+    }
+    previous.forEach(function (link) {
+      link.parentNode.removeChild(link);
+    });
+  }
+
+  if (pending === 0) {
+    pending = 1;
+    loaded();
+  }
+
+  files.forEach(function (file) {
+    var link = document.createElement('link');
+    link.rel = 'stylesheet';
+    link.href = file + '?v=' + version;
+    link.onload = loaded;
+    link.onerror = loaded;
+    document.head.appendChild(link);
+  });
+})(%v, %v);`
+
+// resourceChange describes the kind of resources that changed.
+type resourceChange struct {
+	css bool
+	js  bool
+}
+
+func (c resourceChange) any() bool {
+	return c.css || c.js
+}
+
+// resourceStamp identifies a version of a resource file.
+type resourceStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotResources returns the stamps of the css and js files of fsys.
+func snapshotResources(fsys fs.FS) (map[string]resourceStamp, error) {
+	snapshot := make(map[string]resourceStamp)
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ext := path.Ext(name); d.IsDir() || (ext != ".css" && ext != ".js") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		snapshot[name] = resourceStamp{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// diffResources returns the kind of the resources that differ between the
+// snapshots.
+func diffResources(prev, next map[string]resourceStamp) resourceChange {
+	var change resourceChange
+
+	mark := func(name string) {
+		if path.Ext(name) == ".css" {
+			change.css = true
+		} else {
+			change.js = true
+		}
+	}
+
+	for name, stamp := range next {
+		if prevStamp, ok := prev[name]; !ok || prevStamp != stamp {
+			mark(name)
+		}
+	}
+
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			mark(name)
+		}
+	}
+	return change
+}
+
+// watchResources checks the resources for changes at every LiveReloadInterval
+// and applies them to the open windows once the resources have been unchanged
+// for an interval. It returns when the app is terminated.
+func (d *Driver) watchResources() {
+	interval := d.LiveReloadInterval
+	if interval <= 0 {
+		interval = defaultLiveReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	fsys := d.resourcesFS()
+	snapshot, err := snapshotResources(fsys)
+	if err != nil {
+		log.Error(errors.Wrap(err, "watching resources failed"))
+		return
+	}
+
+	var pending resourceChange
+
+	for range ticker.C {
+		if d.State() == StateTerminated {
+			return
+		}
+
+		next, err := snapshotResources(fsys)
+		if err != nil {
+			log.Error(errors.Wrap(err, "watching resources failed"))
+			continue
+		}
+
+		change := diffResources(snapshot, next)
+		snapshot = next
+
+		if change.any() {
+			pending.css = pending.css || change.css
+			pending.js = pending.js || change.js
+			continue
+		}
+
+		if pending.any() {
+			applied := pending
+			app.UIChan <- func() {
+				d.liveReload(applied)
+			}
+			pending = resourceChange{}
+		}
+	}
+}
+
+// liveReload applies change to the open windows. It must be called on the UI
+// goroutine.
+func (d *Driver) liveReload(change resourceChange) {
+	for _, w := range d.Windows() {
+		win := w.(*window)
+
+		if change.js {
+			log.Infof("window %v: reloading after a js change", win.id)
+			win.reload()
+			continue
+		}
+
+		log.Infof("window %v: reloading stylesheets after a css change", win.id)
+		win.reloadCSS()
+	}
+}
+
+// reloadCSS replaces the stylesheets of the window with the current css files.
+func (w *window) reloadCSS() {
+	files := resourceFilenames(driver.resourcesFS(), "css", ".css")
+	if files == nil {
+		files = []string{}
+	}
+
+	d, err := json.Marshal(files)
+	if err != nil {
+		log.Error(errors.Wrap(err, "reloading stylesheets failed"))
+		return
+	}
+
+	script := fmt.Sprintf(reloadCSSJS, string(d), time.Now().UnixNano())
+	evalJS(w.id, w.ptr, script, logJSError("reloading stylesheets failed"))
+}
+
+// reload loads a new document in the webview. The mounted component is
+// displayed again once it is loaded.
+func (w *window) reload() {
+	w.discardRender()
+
+	w.reloadMutex.Lock()
+	w.reloading = true
+	w.reloadMutex.Unlock()
+
+	native.WindowLoadHTML(w.ptr, windowHTML(w.id, w.config), driver.resourcesBaseURL())
+}
+
+func (w *window) isReloading() bool {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	return w.reloading
+}
+
+func handleWindowReloaded(id uuid.UUID) {
+	ctx, ok := app.Elements().Get(id)
+	if !ok {
+		return
+	}
+	win := ctx.(*window)
+
+	win.reloadMutex.Lock()
+	reloading := win.reloading
+	win.reloading = false
+	win.reloadMutex.Unlock()
+
+	if !reloading {
+		return
+	}
+
+	app.UIChan <- func() {
+		if win.component != nil {
+			win.display()
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package mac
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/murlokswarm/app"
+)
+
+func TestDiffResources(t *testing.T) {
+	now := time.Now()
+
+	prev := map[string]resourceStamp{
+		"css/main.css": {modTime: now, size: 10},
+		"js/main.js":   {modTime: now, size: 10},
+	}
+
+	tests := []struct {
+		scenario string
+		next     map[string]resourceStamp
+		expected resourceChange
+	}{
+		{
+			scenario: "unchanged",
+			next:     prev,
+		},
+		{
+			scenario: "css modified",
+			next: map[string]resourceStamp{
+				"css/main.css": {modTime: now.Add(time.Second), size: 10},
+				"js/main.js":   {modTime: now, size: 10},
+			},
+			expected: resourceChange{css: true},
+		},
+		{
+			scenario: "js removed and css added",
+			next: map[string]resourceStamp{
+				"css/main.css":  {modTime: now, size: 10},
+				"css/theme.css": {modTime: now, size: 42},
+			},
+			expected: resourceChange{css: true, js: true},
+		},
+	}
+
+	for _, test := range tests {
+		if change := diffResources(prev, test.next); change != test.expected {
+			t.Errorf("%v: change should be %+v: %+v", test.scenario, test.expected, change)
+		}
+	}
+}
+
+func TestSnapshotResources(t *testing.T) {
+	snapshot, err := snapshotResources(fstest.MapFS{
+		"css/main.css": {Data: []byte("body {}")},
+		"js/main.js":   {Data: []byte("var x;")},
+		"logo.png":     {Data: []byte("png")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshot) != 2 {
+		t.Errorf("snapshot should only have the css and js files: %v", snapshot)
+	}
+	if stamp := snapshot["css/main.css"]; stamp.size != 7 {
+		t.Errorf("css/main.css size should be 7: %v", stamp.size)
+	}
+}
+
+func TestWindowLiveReload(t *testing.T) {
+	defer func() {
+		driver.ResourcesFS = nil
+	}()
+
+	driver.ResourcesFS = fstest.MapFS{
+		"css/main.css": {Data: []byte("body {}")},
+	}
+
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	if err := win.MountE(&WindowComponent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	app.UIChan <- func() {
+		driver.liveReload(resourceChange{css: true})
+	}
+	waitUI()
+	fakeNative().flush()
+
+	scripts := fakeNative().scripts(win.ptr)
+	if script := scripts[len(scripts)-1]; !strings.Contains(script, `["css/main.css"]`) {
+		t.Errorf("stylesheets should be reloaded: %v", script)
+	}
+
+	app.UIChan <- func() {
+		driver.liveReload(resourceChange{css: true, js: true})
+	}
+	waitUI()
+	fakeNative().flush()
+	waitUI()
+	fakeNative().flush()
+
+	var loaded bool
+	for _, c := range fakeNative().Calls("WindowLoadHTML") {
+		loaded = loaded || c.Args[0] == win.ptr
+	}
+	if !loaded {
+		t.Error("window should be reloaded")
+	}
+	if win.isReloading() {
+		t.Error("window should not be reloading")
+	}
+
+	scripts = fakeNative().scripts(win.ptr)
+	if script := scripts[len(scripts)-1]; !strings.HasPrefix(script, "Mount(") {
+		t.Errorf("component should be displayed again: %v", script)
+	}
+}
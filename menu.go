@@ -1,20 +1,19 @@
 package mac
 
-/*
-#include "menu.h"
-*/
-import "C"
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 	"unsafe"
 
 	"github.com/murlokswarm/app"
-	"github.com/murlokswarm/errors"
 	"github.com/murlokswarm/log"
 	"github.com/murlokswarm/markup"
+	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 )
 
@@ -22,18 +21,27 @@ type menu struct {
 	id        uuid.UUID
 	ptr       unsafe.Pointer
 	component app.Componer
+	shortcuts map[uuid.UUID]menuShortcut
+	root      *menuElem
+
+	// capturing and captured hold the native calls of the renders made by
+	// an onopen handler. They are only accessed on the UI goroutine.
+	capturing bool
+	captured  []func()
+}
+
+// menuShortcut is the shortcut claimed by a menu item.
+type menuShortcut struct {
+	shortcut Shortcut
+	label    string
 }
 
 func newMenu(m app.Menu) *menu {
 	id := uuid.NewV1()
-	cmenu := C.Menu__{
-		ID: C.CString(id.String()),
-	}
-	defer free(unsafe.Pointer(cmenu.ID))
-
 	menu := &menu{
-		id:  id,
-		ptr: C.Menu_New(cmenu),
+		id:        id,
+		ptr:       native.MenuNew(id.String()),
+		shortcuts: make(map[uuid.UUID]menuShortcut),
 	}
 	app.Elements().Add(menu)
 	return menu
@@ -44,74 +52,121 @@ func (m *menu) ID() uuid.UUID {
 }
 
 func (m *menu) Mount(c app.Componer) {
+	if err := m.MountE(c); err != nil {
+		log.Panic(err)
+	}
+}
+
+// MountE mounts c in the menu and returns the markup error instead of
+// panicking.
+func (m *menu) MountE(c app.Componer) error {
 	if m.component != nil {
-		C.Menu_Clear(m.ptr)
+		native.MenuClear(m.ptr)
 		markup.Dismount(m.component)
+		m.component = nil
+		m.root = nil
 	}
+	m.shortcuts = make(map[uuid.UUID]menuShortcut)
 
-	m.component = c
-	root, err := markup.Mount(c, m.ID())
+	node, err := markup.Mount(c, m.ID())
 	if err != nil {
-		log.Panic(err)
-	}
-	if err := m.mount(root); err != nil {
-		log.Panic(err)
+		return err
 	}
 
-	rootID := C.CString(root.ID.String())
-	defer free(unsafe.Pointer(rootID))
+	root, err := m.build(node)
+	if err != nil {
+		markup.Dismount(c)
+		return err
+	}
+	m.component = c
+	m.root = root
 
-	C.Menu_Mount(m.ptr, rootID)
+	m.create(root)
+	native.MenuMount(m.ptr, root.id.String())
+	return nil
 }
 
-func (m *menu) mount(n *markup.Node) (err error) {
+// build returns the model of the menu element described by n and its
+// descendants.
+func (m *menu) build(n *markup.Node) (*menuElem, error) {
+	if n.Type == markup.ComponentNode {
+		n = markup.Root(n.Component)
+	}
+
+	e := &menuElem{id: n.ID}
+
 	switch n.Tag {
 	case "menu":
-		if err = m.mountContainer(n); err != nil {
-			return
+		spec, err := m.containerSpec(n)
+		if err != nil {
+			return nil, err
 		}
+		e.container = spec
 
 	case "menuitem":
-		if err = m.mountItem(n); err != nil {
-			return
+		spec, err := m.itemSpec(n)
+		if err != nil {
+			return nil, err
 		}
+		e.isItem = true
+		e.item = spec
+		e.declared = spec.State
 
 	default:
-		return errors.Newf("%v markup is not supported in a menu context. valid tags are menu and menuitem", n)
+		return nil, &MenuMarkupError{
+			Node:   n.Tag,
+			Reason: "markup is not supported in a menu context. valid tags are menu and menuitem",
+		}
 	}
 
 	for _, child := range n.Children {
-		if child.Type == markup.ComponentNode {
-			child = markup.Root(child.Component)
+		c, err := m.build(child)
+		if err != nil {
+			return nil, err
 		}
-		if err = m.mount(child); err != nil {
-			return
-		}
-		m.associate(n, child)
+		e.children = append(e.children, c)
 	}
-	return
+	return e, nil
 }
 
-func (m *menu) mountContainer(n *markup.Node) error {
+func (m *menu) containerSpec(n *markup.Node) (menuContainerSpec, error) {
 	if n.Parent != nil && n.Parent.Tag != "menu" {
-		return errors.Newf("%v can only have another menu as parent: %v", n, n.Parent)
+		return menuContainerSpec{}, &MenuMarkupError{
+			Node:   n.Tag,
+			Reason: fmt.Sprintf("can only have another menu as parent: %v", n.Parent.Tag),
+		}
 	}
 
 	label, _ := n.Attributes["label"]
-	container := C.MenuContainer__{
-		ID:    C.CString(n.ID.String()),
-		Label: C.CString(label),
+	onopen, _ := n.Attributes["onopen"]
+	onclose, _ := n.Attributes["onclose"]
+
+	// The Window and Help menus of the menu bar get the items Cocoa adds,
+	// such as the window list and the help search field.
+	role, _ := n.Attributes["role"]
+	if len(role) != 0 && role != "window" && role != "help" {
+		return menuContainerSpec{}, &MenuMarkupError{
+			Node:   n.Tag,
+			Reason: fmt.Sprintf("role must be window or help: %v", role),
+		}
 	}
-	defer free(unsafe.Pointer(container.ID))
-	defer free(unsafe.Pointer(container.Label))
 
-	C.Menu_MountContainer(m.ptr, container)
-	return nil
+	return menuContainerSpec{
+		ID:      n.ID.String(),
+		Label:   label,
+		OnOpen:  onopen,
+		OnClose: onclose,
+		Role:    role,
+	}, nil
 }
 
-func (m *menu) mountItem(n *markup.Node) (err error) {
+func (m *menu) itemSpec(n *markup.Node) (spec menuItemSpec, err error) {
 	if n.Parent == nil || n.Parent.Tag != "menu" {
-		return errors.Newf("%v should have a menu as parent: %v", n, n.Parent)
+		err = &MenuMarkupError{
+			Node:   n.Tag,
+			Reason: "should have a menu as parent",
+		}
+		return
 	}
 
 	label, _ := n.Attributes["label"]
@@ -121,92 +176,397 @@ func (m *menu) mountItem(n *markup.Node) (err error) {
 	onclick, _ := n.Attributes["onclick"]
 	disabled, _ := n.Attributes["disabled"]
 	separator, _ := n.Attributes["separator"]
+	checked, hasChecked := n.Attributes["checked"]
+	mixed, hasMixed := n.Attributes["mixed"]
+	group, _ := n.Attributes["group"]
+	value, _ := n.Attributes["value"]
 
 	isDisabled, _ := strconv.ParseBool(disabled)
 	isSeparator, _ := strconv.ParseBool(separator)
+	isChecked, _ := strconv.ParseBool(checked)
+	isMixed, _ := strconv.ParseBool(mixed)
+
+	state := menuItemOff
+	if isChecked {
+		state = menuItemOn
+	}
+	if isMixed {
+		state = menuItemMixed
+	}
+
+	var sc Shortcut
+	if len(shortcut) != 0 {
+		if sc, err = ParseShortcut(shortcut); err != nil {
+			return
+		}
+	}
+	if other, conflict := m.claimShortcut(n.ID, sc, label); conflict {
+		log.Warnf("menu %v: shortcut %v of %q is already used by %q", m.id, sc, label, other)
+	}
 
 	var iconPath string
 	if len(icon) != 0 {
 		iconPath = filepath.Join(app.Resources(), icon)
 		if !app.FileIsSupportedIcon(iconPath) {
-			err = errors.Newf("extension of %v is not supported", iconPath)
+			err = &UnsupportedIconError{Path: iconPath}
 			return
 		}
 		if _, err = os.Stat(iconPath); err != nil {
+			err = &MissingIconError{
+				Path: iconPath,
+				Err:  err,
+			}
 			return
 		}
 	}
 
-	item := C.MenuItem__{
-		ID:        C.CString(n.ID.String()),
-		Label:     C.CString(label),
-		Icon:      C.CString(iconPath),
-		Shortcut:  C.CString(shortcut),
-		Selector:  C.CString(selector),
-		OnClick:   C.CString(onclick),
-		Disabled:  boolToBOOL(isDisabled),
-		Separator: boolToBOOL(isSeparator),
+	spec = menuItemSpec{
+		ID:        n.ID.String(),
+		Label:     label,
+		Icon:      iconPath,
+		Selector:  selector,
+		OnClick:   onclick,
+		Disabled:  isDisabled,
+		Separator: isSeparator,
+
+		KeyEquivalent: sc.keyEquivalent(),
+		KeyModifiers:  sc.Modifiers,
+
+		Checkable: hasChecked || hasMixed || len(group) != 0,
+		State:     state,
+		Group:     group,
+		Value:     value,
 	}
-	defer free(unsafe.Pointer(item.ID))
-	defer free(unsafe.Pointer(item.Label))
-	defer free(unsafe.Pointer(item.Icon))
-	defer free(unsafe.Pointer(item.Shortcut))
-	defer free(unsafe.Pointer(item.Selector))
-	defer free(unsafe.Pointer(item.OnClick))
-
-	C.Menu_MountItem(m.ptr, item)
 	return
 }
 
-func (m *menu) associate(parent *markup.Node, child *markup.Node) {
-	parentID := C.CString(parent.ID.String())
-	childID := C.CString(child.ID.String())
-	defer free(unsafe.Pointer(parentID))
-	defer free(unsafe.Pointer(childID))
+// claimShortcut records the shortcut of the item identified by id. It returns
+// the label of another item of the menu that already uses the shortcut.
+// Conflicts are only reported when the item is mounted or its shortcut
+// changes, so that renders of an unchanged item do not report them again.
+func (m *menu) claimShortcut(id uuid.UUID, sc Shortcut, label string) (other string, conflict bool) {
+	prev, claimed := m.shortcuts[id]
+	delete(m.shortcuts, id)
+	if len(sc.Key) == 0 {
+		return "", false
+	}
+
+	if claimed && prev.shortcut == sc {
+		m.shortcuts[id] = menuShortcut{
+			shortcut: sc,
+			label:    label,
+		}
+		return "", false
+	}
+
+	for otherID, s := range m.shortcuts {
+		if s.shortcut == sc && otherID != id {
+			other, conflict = s.label, true
+			break
+		}
+	}
+
+	m.shortcuts[id] = menuShortcut{
+		shortcut: sc,
+		label:    label,
+	}
+	return other, conflict
+}
+
+// create mounts e and its descendants in the native menu.
+func (m *menu) create(e *menuElem) {
+	runCalls(m.createCalls(nil, e))
+}
+
+// createCalls appends to calls the native calls that mount e and its
+// descendants.
+func (m *menu) createCalls(calls []func(), e *menuElem) []func() {
+	ptr := m.ptr
+
+	if e.isItem {
+		item := e.item
+		return append(calls, func() { native.MenuMountItem(ptr, item) })
+	}
+
+	container := e.container
+	calls = append(calls, func() { native.MenuMountContainer(ptr, container) })
+	for i, child := range e.children {
+		calls = m.createCalls(calls, child)
+		calls = append(calls, m.insertCall(e.id, child.id, i))
+	}
+	return calls
+}
+
+func (m *menu) insertCall(parent, child uuid.UUID, index int) func() {
+	ptr := m.ptr
+	return func() { native.MenuInsert(ptr, parent.String(), child.String(), index) }
+}
+
+// apply performs ops on the native menu. The native calls are collected
+// instead while an onopen handler is called, see handleMenuOpen.
+func (m *menu) apply(ops []menuOp) {
+	calls := m.nativeCalls(ops)
+	if m.capturing {
+		m.captured = append(m.captured, calls...)
+		return
+	}
+	runCalls(calls)
+}
+
+// nativeCalls returns the native calls that perform ops. The elements are
+// copied so the calls can be made once the menu has changed again.
+func (m *menu) nativeCalls(ops []menuOp) []func() {
+	ptr := m.ptr
+	var calls []func()
+
+	for _, op := range ops {
+		switch op.Kind {
+		case menuInsert:
+			calls = m.createCalls(calls, op.Elem)
+			calls = append(calls, m.insertCall(op.Parent, op.Elem.id, op.Index))
+
+		case menuRemove:
+			op.Elem.walk(func(e *menuElem) {
+				delete(m.shortcuts, e.id)
+			})
+			parent, child := op.Parent.String(), op.Elem.id.String()
+			calls = append(calls, func() { native.MenuRemove(ptr, parent, child) })
+
+		case menuMove:
+			calls = append(calls, m.insertCall(op.Parent, op.Elem.id, op.Index))
+
+		case menuUpdateItem:
+			item := op.Elem.item
+			calls = append(calls, func() { native.MenuMountItem(ptr, item) })
+
+		case menuUpdateContainer:
+			container := op.Elem.container
+			calls = append(calls, func() { native.MenuMountContainer(ptr, container) })
+		}
+	}
+	return calls
+}
+
+// capture calls fn and returns the native calls of the renders it made
+// instead of performing them.
+func (m *menu) capture(fn func()) []func() {
+	m.capturing = true
+	defer func() {
+		m.capturing = false
+		m.captured = nil
+	}()
 
-	C.Menu_Associate(m.ptr, parentID, childID)
+	fn()
+	return m.captured
+}
+
+func runCalls(calls []func()) {
+	for _, call := range calls {
+		call()
+	}
 }
 
 func (m *menu) Component() app.Componer {
 	return m.component
 }
 
+// Render updates the mounted menu with the minimal set of operations that
+// reflect s.
 func (m *menu) Render(s markup.Sync) {
-	if err := m.mount(s.Node); err != nil {
+	if m.root == nil {
+		return
+	}
+
+	node := s.Node
+	if node.Type == markup.ComponentNode {
+		node = markup.Root(node.Component)
+	}
+
+	parent, prev, ok := m.find(node.ID)
+	if !ok {
+		log.Errorf("menu %v: rendering failed: node %v is not mounted", m.id, node.ID)
+		return
+	}
+
+	next, err := m.build(node)
+	if err != nil {
 		log.Error(err)
+		return
+	}
+	keepItemStates(prev, next)
+
+	if parent == nil {
+		m.apply(diffMenu(prev, next))
+		m.root = next
+		return
 	}
+
+	// Diffing from the parent handles an element that changes of kind.
+	updated := replaceMenuChild(parent, prev, next)
+	m.apply(diffMenu(parent, updated))
+	parent.children = updated.children
 }
 
-//export onMenuItemClick
-func onMenuItemClick(cid *C.char, cmethod *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-	method := C.GoString(cmethod)
+// find returns the element identified by id and its parent.
+func (m *menu) find(id uuid.UUID) (parent, e *menuElem, ok bool) {
+	if m.root.id == id {
+		return nil, m.root, true
+	}
 
-	if len(method) == 0 {
+	m.root.walk(func(p *menuElem) {
+		for _, child := range p.children {
+			if child.id == id {
+				parent, e, ok = p, child, true
+			}
+		}
+	})
+	return
+}
+
+// replaceMenuChild returns a copy of parent where prev is replaced by next.
+func replaceMenuChild(parent, prev, next *menuElem) *menuElem {
+	p := *parent
+	p.children = make([]*menuElem, len(parent.children))
+
+	for i, child := range parent.children {
+		if child == prev {
+			child = next
+		}
+		p.children[i] = child
+	}
+	return &p
+}
+
+func handleMenuItemClick(menuID uuid.UUID, id uuid.UUID, method string) {
+	app.UIChan <- func() {
+		var arg string
+
+		if ctx, ok := app.Elements().Get(menuID); ok {
+			if e, ok := ctx.(*menu).check(id); ok {
+				d, err := json.Marshal(e)
+				if err != nil {
+					log.Error(errors.Wrap(err, "onMenuItemClick failed"))
+					return
+				}
+				arg = string(d)
+			}
+		}
+
+		if len(method) != 0 {
+			markup.HandleEvent(id, method, arg)
+		}
+	}
+}
+
+// handleMenuHook calls the handler of a menu hook. It is a variable so the
+// tests can observe the hooks.
+var handleMenuHook = markup.HandleEvent
+
+// handleMenuOpen calls the onopen handler of the container identified by id.
+// It is called on the main thread and blocks until the handler returns. The
+// renders of the menu made by the handler are then performed right away, so
+// they appear in the container being opened.
+//
+// The menu does not respond while the handler runs. A handler must not wait
+// for something done on the main thread, such as a javascript evaluation: it
+// is given Driver.MenuOpenTimeout, after which the menu is displayed as is
+// and the renders are performed once the handler returns.
+func handleMenuOpen(menuID uuid.UUID, id uuid.UUID) {
+	var mutex sync.Mutex
+	var abandoned bool
+	rendered := make(chan []func(), 1)
+
+	open := func() {
+		var calls []func()
+		if ctx, ok := app.Elements().Get(menuID); ok {
+			calls = ctx.(*menu).capture(func() {
+				callMenuHook(menuID, id, func(c menuContainerSpec) string {
+					return c.OnOpen
+				})
+			})
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if abandoned {
+			runCalls(calls)
+			return
+		}
+		rendered <- calls
+	}
+
+	timeout := time.After(driver.MenuOpenTimeout)
+
+	select {
+	case app.UIChan <- open:
+	case <-timeout:
+		log.Warnf("menu %v: onopen handler of %v was not called within %v", menuID, id, driver.MenuOpenTimeout)
 		return
 	}
 
+	select {
+	case calls := <-rendered:
+		runCalls(calls)
+		return
+	case <-timeout:
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	select {
+	case calls := <-rendered:
+		runCalls(calls)
+	default:
+		abandoned = true
+		log.Warnf("menu %v: onopen handler of %v did not return within %v", menuID, id, driver.MenuOpenTimeout)
+	}
+}
+
+func handleMenuClose(menuID uuid.UUID, id uuid.UUID) {
 	app.UIChan <- func() {
-		markup.HandleEvent(id, method, "")
+		callMenuHook(menuID, id, func(c menuContainerSpec) string {
+			return c.OnClose
+		})
 	}
 }
 
-//export onMenuCloseFinal
-func onMenuCloseFinal(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
+// callMenuHook calls the handler returned by method for the container
+// identified by id. It must be called on the UI goroutine.
+func callMenuHook(menuID uuid.UUID, id uuid.UUID, method func(c menuContainerSpec) string) {
+	ctx, ok := app.Elements().Get(menuID)
+	if !ok {
+		return
+	}
+
+	m := ctx.(*menu)
+	if m.root == nil {
+		return
+	}
+
+	_, e, ok := m.find(id)
+	if !ok || e.isItem {
+		return
+	}
 
+	if name := method(e.container); len(name) != 0 {
+		handleMenuHook(id, name, "")
+	}
+}
+
+// handleMenuCloseFinal releases a context menu once closed. It is called after
+// the click of the chosen item has been reported, so the dismount is queued
+// after the click handler.
+func handleMenuCloseFinal(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	menu := ctx.(*menu)
 
-	go func() {
-		time.Sleep(time.Millisecond * 42)
-
-		app.UIChan <- func() {
-			markup.Dismount(menu.component)
-			app.Elements().Remove(menu)
-		}
-	}()
+	app.UIChan <- func() {
+		markup.Dismount(menu.component)
+		app.Elements().Remove(menu)
+	}
 }
@@ -8,31 +8,42 @@ typedef struct Menu__ { const char *ID; } Menu__;
 typedef struct MenuContainer__ {
   const char *ID;
   const char *Label;
+  BOOL HasOnOpen;
+  BOOL HasOnClose;
+  const char *Role;
 } MenuContainer__;
 
 typedef struct MenuItem__ {
   const char *ID;
   const char *Label;
   const char *Icon;
-  const char *Shortcut;
   const char *Selector;
   const char *OnClick;
   BOOL Disabled;
   BOOL Separator;
+  const char *KeyEquivalent;
+  int KeyModifiers;
+  BOOL Checkable;
+  int State;
 } MenuItem__;
 
 @interface MenuContainer : NSMenu
 @property NSString *ID;
+@property NSMenuItem *HostItem;
+@property BOOL HasOnOpen;
+@property BOOL HasOnClose;
+@property NSString *Role;
 @end
 
 @interface MenuItem : NSMenuItem
 @property NSString *ID;
+@property NSString *MenuID;
 @property NSString *OnClick;
 @property BOOL IsSeparator;
 @property NSMenuItem *SeparatorItem;
 
 - (void)setSelector:(NSString *)selector;
-- (void)setShortcut:(NSString *)shortcut;
+- (void)setShortcut:(NSString *)key modifiers:(int)modifiers;
 - (void)setSeparator;
 - (void)clicked:(id)sender;
 @end
@@ -41,8 +52,13 @@ typedef struct MenuItem__ {
 @property NSString *ID;
 @property NSMutableDictionary *Elems;
 @property MenuContainer *Root;
+@property BOOL IsContext;
+@property BOOL IsTracking;
 
 - (void)dismountElement:(id)elem;
+- (void)insert:(NSString *)childID into:(NSString *)parentID at:(int)index;
+- (void)remove:(NSString *)childID from:(NSString *)parentID;
+- (void)registerRoles;
 @end
 
 const void *Menu_New(Menu__ m);
@@ -51,7 +67,9 @@ void Menu_Show(const void *ptr);
 void Menu_Dismount(const void *ptr);
 void Menu_MountContainer(const void *ptr, MenuContainer__ container);
 void Menu_MountItem(const void *ptr, MenuItem__ item);
-void Menu_Associate(const void *ptr, const char *parentID, const char *childID);
+void Menu_Insert(const void *ptr, const char *parentID, const char *childID,
+                 int index);
+void Menu_Remove(const void *ptr, const char *parentID, const char *childID);
 void Menu_Clear(const void *ptr);
 
 #endif /* menu_h */
\ No newline at end of file
@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "menu.h"
 #include "_cgo_export.h"
 
@@ -18,10 +21,12 @@ void Menu_Mount(const void *ptr, const char *rootID) {
 void Menu_Show(const void *ptr) {
   Menu *menu = (__bridge Menu *)ptr;
 
-  defer(NSPoint p = [NSApp.keyWindow mouseLocationOutsideOfEventStream];
+  defer(menu.IsContext = YES; menu.IsTracking = YES;
+        NSPoint p = [NSApp.keyWindow mouseLocationOutsideOfEventStream];
         [menu.Root popUpMenuPositioningItem:menu.Root.itemArray[0]
                                  atLocation:p
-                                     inView:NSApp.keyWindow.contentView];);
+                                     inView:NSApp.keyWindow.contentView];
+        menu.IsTracking = NO;);
 }
 
 void Menu_Dismount(const void *ptr) {
@@ -33,19 +38,21 @@ void Menu_MountContainer(const void *ptr, MenuContainer__ c) {
   Menu *menu = (__bridge Menu *)ptr;
   NSString *containerID = [NSString stringWithUTF8String:c.ID];
   NSString *label = [NSString stringWithUTF8String:c.Label];
+  NSString *role = [NSString stringWithUTF8String:c.Role];
 
-  defer(MenuContainer *container = [menu.Elems objectForKey:containerID];
+  onMain(MenuContainer *container = [menu.Elems objectForKey:containerID];
         if (container == nil) {
           container = [[MenuContainer alloc] initWithTitle:label];
           container.ID = containerID;
+          container.delegate = menu;
           [menu.Elems setObject:container forKey:containerID];
-          return;
         }
 
-        for (int i = 0; i < container.numberOfItems;
-             i++) { [menu dismountElement:container.itemArray[i]]; }
-
-            [container removeAllItems];);
+        container.title = label;
+        container.HostItem.title = label;
+        container.HasOnOpen = c.HasOnOpen;
+        container.HasOnClose = c.HasOnClose; container.Role = role;
+        if (NSApp.mainMenu == menu.Root) { [menu registerRoles]; });
 }
 
 void Menu_MountItem(const void *ptr, MenuItem__ it) {
@@ -55,46 +62,42 @@ void Menu_MountItem(const void *ptr, MenuItem__ it) {
   NSString *onClick = [NSString stringWithUTF8String:it.OnClick];
   NSString *icon = [NSString stringWithUTF8String:it.Icon];
   NSString *selector = [NSString stringWithUTF8String:it.Selector];
-  NSString *shortcut = [NSString stringWithUTF8String:it.Shortcut];
+  NSString *key = [NSString stringWithUTF8String:it.KeyEquivalent];
+  int modifiers = it.KeyModifiers;
 
-  defer(MenuItem *item = [menu.Elems objectForKey:itemID]; if (item == nil) {
+  onMain(MenuItem *item = [menu.Elems objectForKey:itemID]; if (item == nil) {
     item = [[MenuItem alloc] init];
     item.ID = itemID;
+    item.MenuID = menu.ID;
     [menu.Elems setObject:item forKey:itemID];
   } item.title = label;
         item.OnClick = onClick; item.enabled = !it.Disabled;
         item.IsSeparator = it.Separator;
+        item.state = it.Checkable ? it.State : NSControlStateValueOff;
 
         if (icon.length != 0) {
           item.image = [[NSImage alloc] initByReferencingFile:icon];
         } else { item.image = nil; }
 
         [item setSelector:selector];
-        [item setShortcut:shortcut];[item setSeparator];);
+        [item setShortcut:key modifiers:modifiers];[item setSeparator];);
 }
 
-void Menu_Associate(const void *ptr, const char *parentID,
-                    const char *childID) {
+void Menu_Insert(const void *ptr, const char *parentID, const char *childID,
+                 int index) {
   Menu *menu = (__bridge Menu *)ptr;
   NSString *parentId = [NSString stringWithUTF8String:parentID];
   NSString *childId = [NSString stringWithUTF8String:childID];
 
-  defer(MenuContainer *parent = [menu.Elems objectForKey:parentId];
-        id child = [menu.Elems objectForKey:childId];
-
-        //  child is a MenuItem.
-        if ([child isKindOfClass:[MenuItem class]]) {
-          MenuItem *c = (MenuItem *)child;
+  onMain([menu insert:childId into:parentId at:index];);
+}
 
-          [parent addItem:c];
-          [c setSeparator];
-          return;
-        }
+void Menu_Remove(const void *ptr, const char *parentID, const char *childID) {
+  Menu *menu = (__bridge Menu *)ptr;
+  NSString *parentId = [NSString stringWithUTF8String:parentID];
+  NSString *childId = [NSString stringWithUTF8String:childID];
 
-        //  child is a MenuContainer.
-        MenuContainer *container = (MenuContainer *)child;
-        MenuItem *item = [[MenuItem alloc] init]; item.title = container.title;
-        item.submenu = container; [parent addItem:item];);
+  onMain([menu remove:childId from:parentId];);
 }
 
 void Menu_Clear(const void *ptr) {
@@ -125,30 +128,25 @@ void Menu_Clear(const void *ptr) {
   self.action = action;
 }
 
-- (void)setShortcut:(NSString *)shortcut {
-  if (shortcut.length == 0) {
-    return;
-  }
-
-  NSArray *keys = [shortcut componentsSeparatedByString:@"+"];
+// modifiers is a ShortcutModifier mask from the shortcut Go parser.
+- (void)setShortcut:(NSString *)key modifiers:(int)modifiers {
+  self.keyEquivalent = key;
   self.keyEquivalentModifierMask = 0;
 
-  for (NSString *k in keys) {
-    if ([k isEqual:@"meta"]) {
-      self.keyEquivalentModifierMask |= NSEventModifierFlagCommand;
-    } else if ([k isEqual:@"ctrl"]) {
-      self.keyEquivalentModifierMask |= NSEventModifierFlagControl;
-    } else if ([k isEqual:@"alt"]) {
-      self.keyEquivalentModifierMask |= NSEventModifierFlagOption;
-    } else if ([k isEqual:@"shift"]) {
-      self.keyEquivalentModifierMask |= NSEventModifierFlagShift;
-    } else if ([k isEqual:@"fn"]) {
-      self.keyEquivalentModifierMask |= NSEventModifierFlagFunction;
-    } else if ([k isEqual:@""]) {
-      self.keyEquivalent = @"+";
-    } else {
-      self.keyEquivalent = k;
-    }
+  if (modifiers & 1) {
+    self.keyEquivalentModifierMask |= NSEventModifierFlagControl;
+  }
+  if (modifiers & 2) {
+    self.keyEquivalentModifierMask |= NSEventModifierFlagOption;
+  }
+  if (modifiers & 4) {
+    self.keyEquivalentModifierMask |= NSEventModifierFlagShift;
+  }
+  if (modifiers & 8) {
+    self.keyEquivalentModifierMask |= NSEventModifierFlagCommand;
+  }
+  if (modifiers & 16) {
+    self.keyEquivalentModifierMask |= NSEventModifierFlagFunction;
   }
 }
 
@@ -174,7 +172,8 @@ void Menu_Clear(const void *ptr) {
 }
 
 - (void)clicked:(id)sender {
-  onMenuItemClick((char *)self.ID.UTF8String, (char *)self.OnClick.UTF8String);
+  onMenuItemClick((char *)self.MenuID.UTF8String, (char *)self.ID.UTF8String,
+                  (char *)self.OnClick.UTF8String);
 }
 @end
 
@@ -184,9 +183,124 @@ void Menu_Clear(const void *ptr) {
 @implementation Menu
 - (instancetype)init {
   self.Elems = [NSMutableDictionary dictionary];
+
+  NSNotificationCenter *center = [NSNotificationCenter defaultCenter];
+  [center addObserver:self
+             selector:@selector(menuDidBeginTracking:)
+                 name:NSMenuDidBeginTrackingNotification
+               object:nil];
+  [center addObserver:self
+             selector:@selector(menuDidEndTracking:)
+                 name:NSMenuDidEndTrackingNotification
+               object:nil];
   return self;
 }
 
+// IsTracking distinguishes the openings of the menu from the key equivalent
+// lookups, which also ask the delegate to update the containers.
+- (void)menuDidBeginTracking:(NSNotification *)notification {
+  if (notification.object == self.Root) {
+    self.IsTracking = YES;
+  }
+}
+
+- (void)menuDidEndTracking:(NSNotification *)notification {
+  if (notification.object == self.Root) {
+    self.IsTracking = NO;
+  }
+}
+
+// hostItem returns the item that displays elem in its parent. Containers are
+// displayed by an item that has them as submenu.
+- (MenuItem *)hostItem:(id)elem create:(BOOL)create {
+  if ([elem isKindOfClass:[MenuItem class]]) {
+    return (MenuItem *)elem;
+  }
+
+  MenuContainer *container = (MenuContainer *)elem;
+  if (container.HostItem == nil && create) {
+    MenuItem *item = [[MenuItem alloc] init];
+    item.title = container.title;
+    item.submenu = container;
+    container.HostItem = item;
+  }
+  return (MenuItem *)container.HostItem;
+}
+
+// detach removes item and its separator from their menu.
+- (void)detach:(MenuItem *)item {
+  NSMenu *container = item.menu;
+  if (container == nil) {
+    return;
+  }
+
+  if (item.SeparatorItem != nil) {
+    [container removeItem:item.SeparatorItem];
+    item.SeparatorItem = nil;
+  }
+  [container removeItem:item];
+}
+
+// nativeIndex converts the index of an element in the Go model to an index in
+// container, where separators are items.
+- (NSInteger)nativeIndex:(int)index in:(NSMenu *)container {
+  int n = 0;
+
+  for (NSInteger i = 0; i < container.numberOfItems; i++) {
+    if (![[container itemAtIndex:i] isKindOfClass:[MenuItem class]]) {
+      continue;
+    }
+    if (n == index) {
+      return i;
+    }
+    n++;
+  }
+  return container.numberOfItems;
+}
+
+- (void)insert:(NSString *)childID into:(NSString *)parentID at:(int)index {
+  MenuContainer *parent = [self.Elems objectForKey:parentID];
+  id child = [self.Elems objectForKey:childID];
+  if (parent == nil || child == nil) {
+    return;
+  }
+
+  MenuItem *item = [self hostItem:child create:YES];
+  [self detach:item];
+  [parent insertItem:item atIndex:[self nativeIndex:index in:parent]];
+  [item setSeparator];
+}
+
+- (void)remove:(NSString *)childID from:(NSString *)parentID {
+  id child = [self.Elems objectForKey:childID];
+  if (child == nil) {
+    return;
+  }
+
+  MenuItem *item = [self hostItem:child create:NO];
+  if (item != nil) {
+    [self detach:item];
+  }
+  [self dismountElement:child];
+}
+
+// registerRoles makes the containers with a role the Window and Help menus of
+// the app. It is called when the menu is the menu bar.
+- (void)registerRoles {
+  for (id elem in self.Elems.allValues) {
+    if (![elem isKindOfClass:[MenuContainer class]]) {
+      continue;
+    }
+
+    MenuContainer *container = (MenuContainer *)elem;
+    if ([container.Role isEqualToString:@"window"]) {
+      NSApp.windowsMenu = container;
+    } else if ([container.Role isEqualToString:@"help"]) {
+      NSApp.helpMenu = container;
+    }
+  }
+}
+
 - (void)dismountElement:(id)elem {
   //  elem is a MenuContainer.
   if ([elem isKindOfClass:[MenuContainer class]]) {
@@ -217,8 +331,31 @@ void Menu_Clear(const void *ptr) {
   }
 }
 
+// menuNeedsUpdate blocks until the onopen handler returns. The operations
+// rendered by the handler are performed by onMenuOpen on the main thread,
+// before the container is displayed.
+- (void)menuNeedsUpdate:(NSMenu *)menu {
+  MenuContainer *container = (MenuContainer *)menu;
+  if (self.IsTracking && container.HasOnOpen) {
+    onMenuOpen((char *)self.ID.UTF8String, (char *)container.ID.UTF8String);
+  }
+}
+
 - (void)menuDidClose:(NSMenu *)menu {
-  onMenuCloseFinal((char *)self.ID.UTF8String);
-  CFBridgingRelease((__bridge void *)self);
+  MenuContainer *container = (MenuContainer *)menu;
+  if (container.HasOnClose) {
+    onMenuClose((char *)self.ID.UTF8String, (char *)container.ID.UTF8String);
+  }
+
+  if (menu != self.Root || !self.IsContext) {
+    return;
+  }
+
+  // The clicked item action is sent after the menu is closed. Finalizing on
+  // the next main queue iteration reports the click first.
+  dispatch_async(dispatch_get_main_queue(), ^{
+    onMenuCloseFinal((char *)self.ID.UTF8String);
+    CFBridgingRelease((__bridge void *)self);
+  });
 }
 @end
\ No newline at end of file
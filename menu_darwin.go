@@ -0,0 +1,112 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#include "menu.h"
+*/
+import "C"
+import "unsafe"
+
+func (b cocoaBackend) MenuNew(id string) unsafe.Pointer {
+	cmenu := C.Menu__{
+		ID: cString(id),
+	}
+	defer free(unsafe.Pointer(cmenu.ID))
+
+	return C.Menu_New(cmenu)
+}
+
+func (b cocoaBackend) MenuMount(menu unsafe.Pointer, rootID string) {
+	crootID := cString(rootID)
+	defer free(unsafe.Pointer(crootID))
+
+	C.Menu_Mount(menu, crootID)
+}
+
+func (b cocoaBackend) MenuShow(menu unsafe.Pointer) {
+	C.Menu_Show(menu)
+}
+
+func (b cocoaBackend) MenuMountContainer(menu unsafe.Pointer, c menuContainerSpec) {
+	container := C.MenuContainer__{
+		ID:         cString(c.ID),
+		Label:      cString(c.Label),
+		HasOnOpen:  boolToBOOL(len(c.OnOpen) != 0),
+		HasOnClose: boolToBOOL(len(c.OnClose) != 0),
+		Role:       cString(c.Role),
+	}
+	defer free(unsafe.Pointer(container.ID))
+	defer free(unsafe.Pointer(container.Label))
+	defer free(unsafe.Pointer(container.Role))
+
+	C.Menu_MountContainer(menu, container)
+}
+
+func (b cocoaBackend) MenuMountItem(menu unsafe.Pointer, i menuItemSpec) {
+	item := C.MenuItem__{
+		ID:        cString(i.ID),
+		Label:     cString(i.Label),
+		Icon:      cString(i.Icon),
+		Selector:  cString(i.Selector),
+		OnClick:   cString(i.OnClick),
+		Disabled:  boolToBOOL(i.Disabled),
+		Separator: boolToBOOL(i.Separator),
+
+		KeyEquivalent: cString(i.KeyEquivalent),
+		KeyModifiers:  C.int(i.KeyModifiers),
+		Checkable:     boolToBOOL(i.Checkable),
+		State:         C.int(i.State),
+	}
+	defer free(unsafe.Pointer(item.ID))
+	defer free(unsafe.Pointer(item.Label))
+	defer free(unsafe.Pointer(item.Icon))
+	defer free(unsafe.Pointer(item.KeyEquivalent))
+	defer free(unsafe.Pointer(item.Selector))
+	defer free(unsafe.Pointer(item.OnClick))
+
+	C.Menu_MountItem(menu, item)
+}
+
+func (b cocoaBackend) MenuInsert(menu unsafe.Pointer, parentID string, childID string, index int) {
+	cparentID := cString(parentID)
+	cchildID := cString(childID)
+	defer free(unsafe.Pointer(cparentID))
+	defer free(unsafe.Pointer(cchildID))
+
+	C.Menu_Insert(menu, cparentID, cchildID, C.int(index))
+}
+
+func (b cocoaBackend) MenuRemove(menu unsafe.Pointer, parentID string, childID string) {
+	cparentID := cString(parentID)
+	cchildID := cString(childID)
+	defer free(unsafe.Pointer(cparentID))
+	defer free(unsafe.Pointer(cchildID))
+
+	C.Menu_Remove(menu, cparentID, cchildID)
+}
+
+func (b cocoaBackend) MenuClear(menu unsafe.Pointer) {
+	C.Menu_Clear(menu)
+}
+
+//export onMenuItemClick
+func onMenuItemClick(cmenuID *C.char, cid *C.char, cmethod *C.char) {
+	handleMenuItemClick(goUUID(cmenuID), goUUID(cid), C.GoString(cmethod))
+}
+
+//export onMenuOpen
+func onMenuOpen(cmenuID *C.char, cid *C.char) {
+	handleMenuOpen(goUUID(cmenuID), goUUID(cid))
+}
+
+//export onMenuClose
+func onMenuClose(cmenuID *C.char, cid *C.char) {
+	handleMenuClose(goUUID(cmenuID), goUUID(cid))
+}
+
+//export onMenuCloseFinal
+func onMenuCloseFinal(cid *C.char) {
+	handleMenuCloseFinal(goUUID(cid))
+}
@@ -3,9 +3,10 @@ package mac
 import (
 	"testing"
 	"time"
-	"unsafe"
 
 	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
 )
 
 type MenuComponent struct {
@@ -69,8 +70,8 @@ func init() {
 }
 
 func TestMenuBar(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	c := &SubMenuComponent{}
 	m := newMenuBar()
@@ -78,8 +79,8 @@ func TestMenuBar(t *testing.T) {
 }
 
 func TestContextMenu(t *testing.T) {
-	driver.running = true
-	defer func() { driver.running = false }()
+	driver.setState(StateRunning)
+	defer driver.setState(StateIdle)
 
 	c := &SubMenuComponent{}
 	m := newContextMenu(app.ContextMenu{})
@@ -155,6 +156,34 @@ func TestMenuMountErrorIconExt(t *testing.T) {
 	t.Error("should panic")
 }
 
+func TestMenuMountE(t *testing.T) {
+	m := newMenu(app.Menu{})
+
+	if err := m.MountE(&MenuComponent{}); err != nil {
+		t.Error(err)
+	}
+
+	err := m.MountE(&MenuComponent{ErrorInvalidTag: true})
+	if _, ok := err.(*MenuMarkupError); !ok {
+		t.Errorf("err should be a *MenuMarkupError: %T", err)
+	}
+
+	err = m.MountE(&MenuComponent{ErrorCompositionItem: true})
+	if _, ok := err.(*MenuMarkupError); !ok {
+		t.Errorf("err should be a *MenuMarkupError: %T", err)
+	}
+
+	err = m.MountE(&MenuComponent{ErrorIconNonexistent: true})
+	if _, ok := err.(*MissingIconError); !ok {
+		t.Errorf("err should be a *MissingIconError: %T", err)
+	}
+
+	err = m.MountE(&MenuComponent{ErrorIconExt: true})
+	if _, ok := err.(*UnsupportedIconError); !ok {
+		t.Errorf("err should be an *UnsupportedIconError: %T", err)
+	}
+}
+
 func TestMenuRender(t *testing.T) {
 	m := newMenu(app.Menu{})
 	c := &MenuComponent{}
@@ -168,13 +197,174 @@ func TestMenuRender(t *testing.T) {
 	app.Render(c)
 }
 
-func TestOnMenuCloseFinal(t *testing.T) {
+func TestMenuHooks(t *testing.T) {
 	m := newMenu(app.Menu{})
 
-	cid := cString(m.ID().String())
-	defer free(unsafe.Pointer(cid))
+	root := &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "menu",
+		Attributes: markup.AttributeMap{
+			"onopen":  "OnOpen",
+			"onclose": "OnClose",
+		},
+	}
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.root = elem
+	m.create(elem)
+	native.MenuMount(m.ptr, root.ID.String())
+	fakeNative().flush()
+
+	_, elems := fakeNative().menu(m.ptr)
+	if c := elems[root.ID.String()].container; c.OnOpen != "OnOpen" || c.OnClose != "OnClose" {
+		t.Fatalf("container should have hooks: %+v", c)
+	}
+
+	opened := make(chan struct{})
+	go func() {
+		fakeNative().openMenu(m.ptr, root.ID.String())
+		close(opened)
+	}()
+
+	select {
+	case <-opened:
+	case <-time.After(time.Second):
+		t.Fatal("opening the menu should return once the hook is called")
+	}
+
+	fakeNative().closeMenu(m.ptr)
+	fakeNative().flush()
+	waitUI()
+
+	if _, ok := app.Elements().Get(m.ID()); ok {
+		t.Error("closed menu should be released")
+	}
+}
+
+func TestMenuContainerRole(t *testing.T) {
+	m := newMenu(app.Menu{})
+	root := &markup.Node{ID: uuid.NewV1(), Tag: "menu"}
+	window := &markup.Node{
+		ID:         uuid.NewV1(),
+		Tag:        "menu",
+		Parent:     root,
+		Attributes: markup.AttributeMap{"label": "Window", "role": "window"},
+	}
+	root.Children = []*markup.Node{window}
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role := elem.children[0].container.Role; role != "window" {
+		t.Errorf("container role is %q, want window", role)
+	}
+
+	window.Attributes["role"] = "view"
+	_, err = m.build(root)
+	if _, ok := err.(*MenuMarkupError); !ok {
+		t.Errorf("err should be a *MenuMarkupError: %T", err)
+	}
+}
+
+// newOpenableMenu mounts a menu whose root calls onOpen when opened.
+func newOpenableMenu(t *testing.T, onOpen func(m *menu, root *markup.Node)) (m *menu, root *markup.Node) {
+	m = newMenu(app.Menu{})
+	root = &markup.Node{
+		ID:         uuid.NewV1(),
+		Tag:        "menu",
+		Attributes: markup.AttributeMap{"onopen": "OnOpen"},
+	}
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.root = elem
+	m.create(elem)
+	native.MenuMount(m.ptr, root.ID.String())
+	fakeNative().flush()
+
+	handleMenuHook = func(id uuid.UUID, method string, arg string) {
+		if id == root.ID && method == "OnOpen" {
+			onOpen(m, root)
+		}
+	}
+	return m, root
+}
+
+func appendMenuItem(m *menu, root *markup.Node, label string) {
+	root.Children = append(root.Children, &markup.Node{
+		ID:         uuid.NewV1(),
+		Tag:        "menuitem",
+		Parent:     root,
+		Attributes: markup.AttributeMap{"label": label},
+	})
+	m.Render(markup.Sync{Scope: markup.FullSync, Node: root})
+}
+
+func TestMenuOpenRender(t *testing.T) {
+	defer func() { handleMenuHook = markup.HandleEvent }()
+
+	m, root := newOpenableMenu(t, func(m *menu, root *markup.Node) {
+		appendMenuItem(m, root, "Recent")
+	})
+
+	displayed := fakeNative().openMenu(m.ptr, root.ID.String())
+	if len(displayed) != 1 || displayed[0].item.Label != "Recent" {
+		t.Fatalf("rendered item should be displayed in the opened menu: %+v", displayed)
+	}
+
+	displayed = fakeNative().openMenu(m.ptr, root.ID.String())
+	if len(displayed) != 2 {
+		t.Fatalf("2 items should be displayed: %+v", displayed)
+	}
+
+	waitUI()
+	appendMenuItem(m, root, "Other")
+	fakeNative().flush()
+
+	if _, elems := fakeNative().menu(m.ptr); len(elems[root.ID.String()].children) != 3 {
+		t.Error("renders outside onopen should be applied")
+	}
+}
+
+func TestMenuOpenTimeout(t *testing.T) {
+	defer func(d time.Duration) {
+		driver.MenuOpenTimeout = d
+		handleMenuHook = markup.HandleEvent
+	}(driver.MenuOpenTimeout)
+	driver.MenuOpenTimeout = time.Millisecond * 10
+
+	m, root := newOpenableMenu(t, func(m *menu, root *markup.Node) {
+		time.Sleep(time.Millisecond * 100)
+		appendMenuItem(m, root, "Slow")
+	})
+
+	start := time.Now()
+	displayed := fakeNative().openMenu(m.ptr, root.ID.String())
+	if d := time.Since(start); d >= time.Millisecond*100 {
+		t.Errorf("opening the menu should not wait for the handler: %v", d)
+	}
+	if len(displayed) != 0 {
+		t.Errorf("menu should be displayed as is: %+v", displayed)
+	}
+
+	waitUI()
+	fakeNative().flush()
+
+	if _, elems := fakeNative().menu(m.ptr); len(elems[root.ID.String()].children) != 1 {
+		t.Error("the renders of a late handler should be applied")
+	}
+}
+
+func TestOnMenuCloseFinal(t *testing.T) {
+	m := newMenu(app.Menu{})
 
-	onMenuCloseFinal(cid)
+	handleMenuCloseFinal(m.ID())
 	time.Sleep(time.Millisecond * 50)
-	onMenuCloseFinal(cid)
+	handleMenuCloseFinal(m.ID())
 }
@@ -1,10 +1,9 @@
 package mac
 
-/*
-#include "driver.h"
-*/
-import "C"
-import "github.com/murlokswarm/app"
+import (
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+)
 
 type menuBar struct {
 	*menu
@@ -17,9 +16,23 @@ func newMenuBar() *menuBar {
 }
 
 func (m *menuBar) Mount(c app.Componer) {
-	driver.mustRun()
-	m.menu.Mount(c)
-	C.Driver_SetMenuBar(m.ptr)
+	if err := m.MountE(c); err != nil {
+		log.Panic(err)
+	}
+}
+
+// MountE mounts c in the menu bar. It returns the markup error instead of
+// panicking.
+func (m *menuBar) MountE(c app.Componer) error {
+	if err := driver.checkRunning(); err != nil {
+		return err
+	}
+	if err := m.menu.MountE(c); err != nil {
+		return err
+	}
+
+	native.SetMenuBar(m.ptr)
+	return nil
 }
 
 func (m *menuBar) Component() app.Componer {
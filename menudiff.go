@@ -0,0 +1,253 @@
+package mac
+
+import (
+	"sort"
+
+	"github.com/satori/go.uuid"
+)
+
+// menuElem is the model of a container or an item mounted in a menu.
+// declared is the state of an item described by the markup, which differs
+// from the displayed state once the item is clicked.
+type menuElem struct {
+	id        uuid.UUID
+	isItem    bool
+	container menuContainerSpec
+	item      menuItemSpec
+	declared  menuItemState
+	children  []*menuElem
+}
+
+// walk calls fn with e and its descendants.
+func (e *menuElem) walk(fn func(e *menuElem)) {
+	fn(e)
+	for _, child := range e.children {
+		child.walk(fn)
+	}
+}
+
+// menuOpKind describes an operation performed on a mounted menu.
+type menuOpKind int
+
+// Constants that define the menu operations.
+const (
+	// menuInsert mounts the element and its descendants, then inserts it in
+	// its parent.
+	menuInsert menuOpKind = iota
+
+	// menuRemove removes the element from its parent and dismounts it.
+	menuRemove
+
+	// menuMove moves the element to another position in its parent.
+	menuMove
+
+	// menuUpdateItem updates the fields of an item.
+	menuUpdateItem
+
+	// menuUpdateContainer updates the label of a container.
+	menuUpdateContainer
+)
+
+func (k menuOpKind) String() string {
+	switch k {
+	case menuInsert:
+		return "insert"
+
+	case menuRemove:
+		return "remove"
+
+	case menuMove:
+		return "move"
+
+	case menuUpdateItem:
+		return "update item"
+
+	default:
+		return "update container"
+	}
+}
+
+// menuOp is an operation that brings a mounted menu closer to its new model.
+// Index is the position of the element in its parent once the operations that
+// precede it are applied.
+type menuOp struct {
+	Kind   menuOpKind
+	Parent uuid.UUID
+	Elem   *menuElem
+	Index  int
+}
+
+// diffMenu returns the operations that turn prev into next. Both elements
+// must have the same ID. Elements are matched across renders by ID, and the
+// moves are limited to the elements outside of the longest sequence of
+// children that kept their order.
+// Removals come first so that an element that changes of parent is
+// dismounted before being mounted again.
+func diffMenu(prev, next *menuElem) []menuOp {
+	ops := diffMenuElem(prev, next)
+
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Kind == menuRemove && ops[j].Kind != menuRemove
+	})
+	return ops
+}
+
+func diffMenuElem(prev, next *menuElem) []menuOp {
+	var ops []menuOp
+
+	if prev.isItem {
+		if prev.item != next.item {
+			ops = append(ops, menuOp{Kind: menuUpdateItem, Elem: next})
+		}
+		return ops
+	}
+
+	if prev.container != next.container {
+		ops = append(ops, menuOp{Kind: menuUpdateContainer, Elem: next})
+	}
+	return append(ops, diffMenuChildren(prev, next)...)
+}
+
+func diffMenuChildren(prev, next *menuElem) []menuOp {
+	var ops []menuOp
+
+	nextIndexes := make(map[uuid.UUID]int, len(next.children))
+	for i, child := range next.children {
+		nextIndexes[child.id] = i
+	}
+
+	// Removing the elements that are not in next, or that changed from an
+	// item to a container or the opposite.
+	prevChildren := make(map[uuid.UUID]*menuElem, len(prev.children))
+	var current []*menuElem
+
+	for _, child := range prev.children {
+		i, ok := nextIndexes[child.id]
+		if !ok || next.children[i].isItem != child.isItem {
+			ops = append(ops, menuOp{
+				Kind:   menuRemove,
+				Parent: prev.id,
+				Elem:   child,
+			})
+			continue
+		}
+
+		prevChildren[child.id] = child
+		current = append(current, child)
+	}
+
+	positions := make([]int, len(current))
+	for i, child := range current {
+		positions[i] = nextIndexes[child.id]
+	}
+
+	stable := make(map[uuid.UUID]bool, len(current))
+	for _, i := range longestIncreasingSubsequence(positions) {
+		stable[current[i].id] = true
+	}
+
+	// Placing the children from the last to the first, each one before its
+	// next sibling.
+	var updates []menuOp
+
+	for i := len(next.children) - 1; i >= 0; i-- {
+		child := next.children[i]
+
+		var anchor *menuElem
+		if i+1 < len(next.children) {
+			anchor = next.children[i+1]
+		}
+
+		prevChild, ok := prevChildren[child.id]
+		if !ok {
+			current = insertMenuElem(current, child, anchor)
+			ops = append(ops, menuOp{
+				Kind:   menuInsert,
+				Parent: next.id,
+				Elem:   child,
+				Index:  indexOfMenuElem(current, child.id),
+			})
+			continue
+		}
+
+		if !stable[child.id] {
+			current = removeMenuElem(current, child.id)
+			current = insertMenuElem(current, child, anchor)
+			ops = append(ops, menuOp{
+				Kind:   menuMove,
+				Parent: next.id,
+				Elem:   child,
+				Index:  indexOfMenuElem(current, child.id),
+			})
+		}
+
+		updates = append(diffMenuElem(prevChild, child), updates...)
+	}
+	return append(ops, updates...)
+}
+
+func indexOfMenuElem(elems []*menuElem, id uuid.UUID) int {
+	for i, e := range elems {
+		if e.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeMenuElem(elems []*menuElem, id uuid.UUID) []*menuElem {
+	i := indexOfMenuElem(elems, id)
+	if i == -1 {
+		return elems
+	}
+	return append(elems[:i:i], elems[i+1:]...)
+}
+
+// insertMenuElem inserts e before anchor, or at the end when anchor is nil.
+func insertMenuElem(elems []*menuElem, e *menuElem, anchor *menuElem) []*menuElem {
+	i := len(elems)
+	if anchor != nil {
+		if j := indexOfMenuElem(elems, anchor.id); j != -1 {
+			i = j
+		}
+	}
+
+	elems = append(elems, nil)
+	copy(elems[i+1:], elems[i:])
+	elems[i] = e
+	return elems
+}
+
+// longestIncreasingSubsequence returns the indexes of a longest strictly
+// increasing subsequence of s.
+func longestIncreasingSubsequence(s []int) []int {
+	var tails []int
+	prev := make([]int, len(s))
+
+	for i, v := range s {
+		j := sort.Search(len(tails), func(k int) bool {
+			return s[tails[k]] >= v
+		})
+
+		prev[i] = -1
+		if j > 0 {
+			prev[i] = tails[j-1]
+		}
+
+		if j == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[j] = i
+		}
+	}
+
+	if len(tails) == 0 {
+		return nil
+	}
+
+	lis := make([]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		lis[i] = k
+	}
+	return lis
+}
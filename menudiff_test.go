@@ -0,0 +1,246 @@
+package mac
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
+)
+
+func testMenuItem(label string) *menuElem {
+	id := uuid.NewV1()
+	return &menuElem{
+		id:     id,
+		isItem: true,
+		item: menuItemSpec{
+			ID:    id.String(),
+			Label: label,
+		},
+	}
+}
+
+func testMenuContainer(id uuid.UUID, children ...*menuElem) *menuElem {
+	return &menuElem{
+		id:        id,
+		container: menuContainerSpec{ID: id.String()},
+		children:  children,
+	}
+}
+
+// applyTestMenuOps applies ops to a copy of the children IDs of root and
+// returns them.
+func applyTestMenuOps(root *menuElem, ops []menuOp) []uuid.UUID {
+	var children []uuid.UUID
+	for _, c := range root.children {
+		children = append(children, c.id)
+	}
+
+	remove := func(id uuid.UUID) {
+		for i, c := range children {
+			if c == id {
+				children = append(children[:i:i], children[i+1:]...)
+				return
+			}
+		}
+	}
+
+	for _, op := range ops {
+		switch op.Kind {
+		case menuRemove:
+			remove(op.Elem.id)
+
+		case menuInsert, menuMove:
+			remove(op.Elem.id)
+			children = append(children, uuid.Nil)
+			copy(children[op.Index+1:], children[op.Index:])
+			children[op.Index] = op.Elem.id
+		}
+	}
+
+	if len(children) == 0 {
+		return nil
+	}
+	return children
+}
+
+func countTestMenuOps(ops []menuOp) map[menuOpKind]int {
+	count := make(map[menuOpKind]int)
+	for _, op := range ops {
+		count[op.Kind]++
+	}
+	return count
+}
+
+func TestDiffMenu(t *testing.T) {
+	rootID := uuid.NewV1()
+	a, b, c, d := testMenuItem("a"), testMenuItem("b"), testMenuItem("c"), testMenuItem("d")
+	prev := testMenuContainer(rootID, a, b, c, d)
+
+	disabled := *b
+	disabled.item.Disabled = true
+	e := testMenuItem("e")
+
+	tests := []struct {
+		scenario string
+		next     *menuElem
+		expected map[menuOpKind]int
+	}{
+		{
+			scenario: "unchanged",
+			next:     testMenuContainer(rootID, a, b, c, d),
+			expected: map[menuOpKind]int{},
+		},
+		{
+			scenario: "item updated",
+			next:     testMenuContainer(rootID, a, &disabled, c, d),
+			expected: map[menuOpKind]int{menuUpdateItem: 1},
+		},
+		{
+			scenario: "first item moved to the end",
+			next:     testMenuContainer(rootID, b, c, d, a),
+			expected: map[menuOpKind]int{menuMove: 1},
+		},
+		{
+			scenario: "items swapped",
+			next:     testMenuContainer(rootID, a, c, b, d),
+			expected: map[menuOpKind]int{menuMove: 1},
+		},
+		{
+			scenario: "item inserted and item removed",
+			next:     testMenuContainer(rootID, a, e, c, d),
+			expected: map[menuOpKind]int{menuInsert: 1, menuRemove: 1},
+		},
+	}
+
+	for _, test := range tests {
+		ops := diffMenu(prev, test.next)
+
+		if count := countTestMenuOps(ops); !reflect.DeepEqual(count, test.expected) {
+			t.Errorf("%v: operations should be %v: %v", test.scenario, test.expected, count)
+		}
+
+		var expected []uuid.UUID
+		for _, child := range test.next.children {
+			expected = append(expected, child.id)
+		}
+		if children := applyTestMenuOps(prev, ops); !reflect.DeepEqual(children, expected) {
+			t.Errorf("%v: children should be %v: %v", test.scenario, expected, children)
+		}
+	}
+}
+
+func TestDiffMenuRandom(t *testing.T) {
+	rootID := uuid.NewV1()
+	rnd := rand.New(rand.NewSource(42))
+
+	for n := 0; n < 200; n++ {
+		var prevChildren []*menuElem
+		for i := rnd.Intn(10); i > 0; i-- {
+			prevChildren = append(prevChildren, testMenuItem("prev"))
+		}
+
+		var nextChildren []*menuElem
+		for _, i := range rnd.Perm(len(prevChildren)) {
+			if rnd.Intn(4) != 0 {
+				nextChildren = append(nextChildren, prevChildren[i])
+			}
+		}
+		for i := rnd.Intn(3); i > 0; i-- {
+			j := rnd.Intn(len(nextChildren) + 1)
+			nextChildren = append(nextChildren[:j], append([]*menuElem{testMenuItem("next")}, nextChildren[j:]...)...)
+		}
+
+		prev := testMenuContainer(rootID, prevChildren...)
+		next := testMenuContainer(rootID, nextChildren...)
+
+		var expected []uuid.UUID
+		for _, child := range nextChildren {
+			expected = append(expected, child.id)
+		}
+
+		if children := applyTestMenuOps(prev, diffMenu(prev, next)); !reflect.DeepEqual(children, expected) {
+			t.Fatalf("children should be %v: %v", expected, children)
+		}
+	}
+}
+
+func TestLongestIncreasingSubsequence(t *testing.T) {
+	tests := []struct {
+		s        []int
+		expected []int
+	}{
+		{s: nil, expected: nil},
+		{s: []int{0, 1, 2}, expected: []int{0, 1, 2}},
+		{s: []int{3, 0, 1, 2}, expected: []int{1, 2, 3}},
+		{s: []int{1, 0, 3, 2, 4}, expected: []int{1, 3, 4}},
+	}
+
+	for _, test := range tests {
+		if lis := longestIncreasingSubsequence(test.s); !reflect.DeepEqual(lis, test.expected) {
+			t.Errorf("%v: subsequence should be %v: %v", test.s, test.expected, lis)
+		}
+	}
+}
+
+func TestMenuRenderDiff(t *testing.T) {
+	m := newMenu(app.Menu{})
+
+	root := &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "menu",
+	}
+	for _, label := range []string{"Cut", "Copy", "Paste"} {
+		root.Children = append(root.Children, &markup.Node{
+			ID:         uuid.NewV1(),
+			Tag:        "menuitem",
+			Parent:     root,
+			Attributes: markup.AttributeMap{"label": label},
+		})
+	}
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.root = elem
+	m.create(elem)
+	fakeNative().flush()
+
+	before := len(fakeNative().Calls("MenuMountItem"))
+
+	paste := root.Children[2]
+	paste.Attributes["disabled"] = "true"
+	m.Render(markup.Sync{Scope: markup.AttrSync, Node: paste})
+
+	root.Children = []*markup.Node{root.Children[2], root.Children[0], root.Children[1]}
+	m.Render(markup.Sync{Scope: markup.FullSync, Node: root})
+	fakeNative().flush()
+
+	if n := len(fakeNative().Calls("MenuMountItem")) - before; n != 1 {
+		t.Errorf("1 item should be updated: %v", n)
+	}
+	for _, name := range []string{"MenuClear", "MenuRemove"} {
+		for _, c := range fakeNative().Calls(name) {
+			if c.Args[0] == m.ptr {
+				t.Errorf("%v should not be called", name)
+			}
+		}
+	}
+
+	_, elems := fakeNative().menu(m.ptr)
+	children := elems[root.ID.String()].children
+	expected := []string{
+		root.Children[0].ID.String(),
+		root.Children[1].ID.String(),
+		root.Children[2].ID.String(),
+	}
+	if !reflect.DeepEqual(children, expected) {
+		t.Errorf("children should be %v: %v", expected, children)
+	}
+	if !elems[paste.ID.String()].item.Disabled {
+		t.Error("paste should be disabled")
+	}
+}
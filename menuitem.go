@@ -0,0 +1,91 @@
+package mac
+
+import "github.com/satori/go.uuid"
+
+// menuItemState is the state displayed by a checkable menu item. Its values
+// match the Cocoa control states.
+type menuItemState int
+
+const (
+	menuItemMixed menuItemState = -1
+	menuItemOff   menuItemState = 0
+	menuItemOn    menuItemState = 1
+)
+
+// MenuItemEvent is passed to the onclick handler of the menu items that have
+// a checked, mixed or group attribute.
+// Clicking such an item checks it, or unchecks it when it is checked and has
+// no group. Checking an item unchecks the items of the menu that share its
+// group. The state set by a click is kept across renders until the checked
+// or mixed attribute of the item changes in the markup.
+type MenuItemEvent struct {
+	Checked bool   `json:"checked"`
+	Group   string `json:"group,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+// keepItemStates gives the items of next the state displayed by their
+// previous version when their markup still describes the same state. It
+// prevents a render from reverting the states set by clicks.
+func keepItemStates(prev, next *menuElem) {
+	prevItems := make(map[uuid.UUID]*menuElem)
+	prev.walk(func(e *menuElem) {
+		if e.isItem {
+			prevItems[e.id] = e
+		}
+	})
+
+	next.walk(func(e *menuElem) {
+		if !e.isItem {
+			return
+		}
+		if p, ok := prevItems[e.id]; ok && p.declared == e.declared {
+			e.item.State = p.item.State
+		}
+	})
+}
+
+// check updates the state of the checkable item identified by id after a
+// click. It must be called on the UI goroutine.
+func (m *menu) check(id uuid.UUID) (e MenuItemEvent, ok bool) {
+	if m.root == nil {
+		return
+	}
+
+	_, elem, ok := m.find(id)
+	if !ok || !elem.isItem || !elem.item.Checkable {
+		return e, false
+	}
+
+	state := menuItemOn
+	if elem.item.State == menuItemOn && len(elem.item.Group) == 0 {
+		state = menuItemOff
+	}
+
+	var ops []menuOp
+	setState := func(item *menuElem, state menuItemState) {
+		if item.item.State == state {
+			return
+		}
+		item.item.State = state
+		ops = append(ops, menuOp{Kind: menuUpdateItem, Elem: item})
+	}
+
+	setState(elem, state)
+
+	if group := elem.item.Group; len(group) != 0 {
+		m.root.walk(func(other *menuElem) {
+			if other != elem && other.isItem && other.item.Group == group {
+				setState(other, menuItemOff)
+			}
+		})
+	}
+
+	m.apply(ops)
+
+	return MenuItemEvent{
+		Checked: state == menuItemOn,
+		Group:   elem.item.Group,
+		Value:   elem.item.Value,
+	}, true
+}
@@ -0,0 +1,141 @@
+package mac
+
+import (
+	"testing"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
+)
+
+func TestMenuItemCheck(t *testing.T) {
+	m := newMenu(app.Menu{})
+
+	root := &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "menu",
+	}
+	item := func(attrs markup.AttributeMap) *markup.Node {
+		n := &markup.Node{
+			ID:         uuid.NewV1(),
+			Tag:        "menuitem",
+			Parent:     root,
+			Attributes: attrs,
+		}
+		root.Children = append(root.Children, n)
+		return n
+	}
+
+	sidebar := item(markup.AttributeMap{"label": "Show Sidebar", "checked": "false"})
+	byName := item(markup.AttributeMap{"label": "Name", "group": "sort", "value": "name", "checked": "true"})
+	byDate := item(markup.AttributeMap{"label": "Date", "group": "sort", "value": "date"})
+	plain := item(markup.AttributeMap{"label": "Quit"})
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.root = elem
+	m.create(elem)
+
+	state := func(n *markup.Node) menuItemState {
+		_, elems := fakeNative().menu(m.ptr)
+		return elems[n.ID.String()].item.State
+	}
+
+	fakeNative().clickMenuItem(m.ptr, sidebar.ID.String())
+	fakeNative().flush()
+	waitUI()
+	fakeNative().flush()
+
+	if s := state(sidebar); s != menuItemOn {
+		t.Errorf("sidebar should be checked: %v", s)
+	}
+
+	e, ok := m.check(sidebar.ID)
+	if !ok || e.Checked {
+		t.Errorf("sidebar should be unchecked: %+v", e)
+	}
+
+	e, ok = m.check(byDate.ID)
+	if !ok || !e.Checked || e.Group != "sort" || e.Value != "date" {
+		t.Errorf("date should be checked: %+v", e)
+	}
+	if e, _ = m.check(byDate.ID); !e.Checked {
+		t.Error("checked group item should stay checked")
+	}
+	fakeNative().flush()
+
+	if s := state(byName); s != menuItemOff {
+		t.Errorf("name should be unchecked: %v", s)
+	}
+	if s := state(byDate); s != menuItemOn {
+		t.Errorf("date should be checked: %v", s)
+	}
+
+	if _, ok = m.check(plain.ID); ok {
+		t.Error("plain item should not be checkable")
+	}
+}
+
+func TestMenuItemStateAcrossRenders(t *testing.T) {
+	m := newMenu(app.Menu{})
+
+	root := &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "menu",
+	}
+	item := func(attrs markup.AttributeMap) *markup.Node {
+		n := &markup.Node{
+			ID:         uuid.NewV1(),
+			Tag:        "menuitem",
+			Parent:     root,
+			Attributes: attrs,
+		}
+		root.Children = append(root.Children, n)
+		return n
+	}
+
+	byName := item(markup.AttributeMap{"label": "Name", "group": "sort", "checked": "true"})
+	byDate := item(markup.AttributeMap{"label": "Date", "group": "sort"})
+
+	elem, err := m.build(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.root = elem
+	m.create(elem)
+
+	state := func(n *markup.Node) menuItemState {
+		_, e, _ := m.find(n.ID)
+		return e.item.State
+	}
+
+	m.check(byDate.ID)
+
+	// A render caused by another change keeps the clicked state.
+	byName.Attributes["label"] = "By Name"
+	m.Render(markup.Sync{Scope: markup.FullSync, Node: root})
+
+	if s := state(byName); s != menuItemOff {
+		t.Errorf("name should stay unchecked: %v", s)
+	}
+	if s := state(byDate); s != menuItemOn {
+		t.Errorf("date should stay checked: %v", s)
+	}
+
+	// A render that changes the checked attributes sets the state.
+	byName.Attributes["checked"] = "false"
+	byDate.Attributes["checked"] = "true"
+	m.Render(markup.Sync{Scope: markup.FullSync, Node: root})
+	byName.Attributes["checked"] = "true"
+	byDate.Attributes["checked"] = "false"
+	m.Render(markup.Sync{Scope: markup.FullSync, Node: root})
+
+	if s := state(byName); s != menuItemOn {
+		t.Errorf("name should be checked by the markup: %v", s)
+	}
+	if s := state(byDate); s != menuItemOff {
+		t.Errorf("date should be unchecked by the markup: %v", s)
+	}
+}
@@ -0,0 +1,150 @@
+package mac
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// StandardMenuBar generates the markup of the standard macOS menu bar: the
+// application, Edit, Window and Help menus, with the selectors and shortcuts
+// Cocoa uses. A menu bar component returns it from its Render method:
+//
+//	func (m *MenuBar) Render() string {
+//		return mac.StandardMenuBar{
+//			AppItems: `<menuitem label="Preferences…" shortcut="meta+," onclick="OnPreferences" separator="true" />`,
+//			Menus:    `<menu label="View">…</menu>`,
+//		}.Markup()
+//	}
+//
+// The fields that end with Items or Menus are markup inserted among the
+// standard elements. They are rendered with the component, so they can refer
+// to its fields and handlers. A separator follows each group of standard
+// items; extension items that need one set their separator attribute.
+type StandardMenuBar struct {
+	// AppName is displayed in the application menu. It defaults to the name
+	// of the app bundle.
+	AppName string
+
+	// AppItems are inserted after the About item of the application menu.
+	AppItems string
+
+	// FileMenus are inserted between the application and Edit menus.
+	FileMenus string
+
+	// EditItems are appended to the Edit menu.
+	EditItems string
+
+	// Menus are inserted between the Edit and Window menus.
+	Menus string
+
+	// WindowItems are inserted before the Bring All to Front item of the
+	// Window menu.
+	WindowItems string
+
+	// HelpItems replace the default item of the Help menu.
+	HelpItems string
+}
+
+// standardMenuItem describes an item of a standard menu.
+type standardMenuItem struct {
+	label     string
+	selector  string
+	shortcut  string
+	separator bool
+}
+
+// Markup returns the markup of the menu bar.
+func (b StandardMenuBar) Markup() string {
+	name := b.AppName
+	if len(name) == 0 {
+		name = driver.AppName()
+	}
+
+	var m strings.Builder
+	m.WriteString("<menu>\n")
+
+	writeStandardMenu(&m, name, "", b.AppItems,
+		[]standardMenuItem{
+			{label: "About " + name, selector: "orderFrontStandardAboutPanel:", separator: true},
+		},
+		[]standardMenuItem{
+			{label: "Hide " + name, selector: "hide:", shortcut: "meta+h"},
+			{label: "Hide Others", selector: "hideOtherApplications:", shortcut: "alt+meta+h"},
+			{label: "Show All", selector: "unhideAllApplications:", separator: true},
+			{label: "Quit " + name, selector: "terminate:", shortcut: "meta+q"},
+		},
+	)
+
+	m.WriteString(b.FileMenus)
+
+	writeStandardMenu(&m, "Edit", "", b.EditItems,
+		[]standardMenuItem{
+			{label: "Undo", selector: "undo:", shortcut: "meta+z"},
+			{label: "Redo", selector: "redo:", shortcut: "shift+meta+z", separator: true},
+			{label: "Cut", selector: "cut:", shortcut: "meta+x"},
+			{label: "Copy", selector: "copy:", shortcut: "meta+c"},
+			{label: "Paste", selector: "paste:", shortcut: "meta+v"},
+			{label: "Paste and Match Style", selector: "pasteAsPlainText:", shortcut: "alt+shift+meta+v"},
+			{label: "Delete", selector: "delete:"},
+			{label: "Select All", selector: "selectAll:", shortcut: "meta+a", separator: true},
+		},
+		nil,
+	)
+
+	m.WriteString(b.Menus)
+
+	writeStandardMenu(&m, "Window", "window", b.WindowItems,
+		[]standardMenuItem{
+			{label: "Minimize", selector: "performMiniaturize:", shortcut: "meta+m"},
+			{label: "Zoom", selector: "performZoom:"},
+			{label: "Enter Full Screen", selector: "toggleFullScreen:", shortcut: "ctrl+meta+f", separator: true},
+			{label: "Close", selector: "performClose:", shortcut: "meta+w", separator: true},
+		},
+		[]standardMenuItem{
+			{label: "Bring All to Front", selector: "arrangeInFront:"},
+		},
+	)
+
+	var help []standardMenuItem
+	if len(b.HelpItems) == 0 {
+		help = []standardMenuItem{
+			{label: name + " Help", selector: "showHelp:", shortcut: "meta+?"},
+		}
+	}
+	writeStandardMenu(&m, "Help", "help", b.HelpItems, help, nil)
+
+	m.WriteString("</menu>")
+	return m.String()
+}
+
+// writeStandardMenu writes a menu that contains the standard items before,
+// the custom items and the standard items after. A menu with a role is
+// registered as the Window or Help menu of the app.
+func writeStandardMenu(m *strings.Builder, label, role, items string, before, after []standardMenuItem) {
+	fmt.Fprintf(m, "<menu label=\"%v\"", html.EscapeString(label))
+	if len(role) != 0 {
+		fmt.Fprintf(m, " role=\"%v\"", role)
+	}
+	m.WriteString(">\n")
+	writeStandardMenuItems(m, before)
+	if len(items) != 0 {
+		m.WriteString(items)
+		m.WriteString("\n")
+	}
+	writeStandardMenuItems(m, after)
+	m.WriteString("</menu>\n")
+}
+
+func writeStandardMenuItems(m *strings.Builder, items []standardMenuItem) {
+	for _, it := range items {
+		fmt.Fprintf(m, "<menuitem label=\"%v\" selector=\"%v\"", html.EscapeString(it.label), it.selector)
+		if len(it.shortcut) != 0 {
+			fmt.Fprintf(m, " shortcut=\"%v\"", it.shortcut)
+		}
+		if it.separator {
+			m.WriteString(" separator=\"true\"")
+		}
+		m.WriteString(" />\n")
+	}
+}
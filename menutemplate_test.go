@@ -0,0 +1,88 @@
+package mac
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStandardMenuBar(t *testing.T) {
+	b := StandardMenuBar{
+		AppName:     "Hello & Co",
+		AppItems:    `<menuitem label="Preferences…" shortcut="meta+," separator="true" />`,
+		FileMenus:   `<menu label="File"></menu>`,
+		EditItems:   `<menuitem label="Find" shortcut="meta+f" />`,
+		Menus:       `<menu label="View"></menu>`,
+		WindowItems: `<menuitem label="Tile" />`,
+	}
+	m := b.Markup()
+
+	shortcuts := make(map[string]string)
+	for _, match := range regexp.MustCompile(`label="([^"]*)"[^>]*shortcut="([^"]*)"`).FindAllStringSubmatch(m, -1) {
+		sc, err := ParseShortcut(match[2])
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if other, ok := shortcuts[sc.String()]; ok {
+			t.Errorf("shortcut %v of %q is already used by %q", sc, match[1], other)
+		}
+		shortcuts[sc.String()] = match[1]
+	}
+
+	for _, selector := range []string{
+		"orderFrontStandardAboutPanel:",
+		"terminate:",
+		"copy:",
+		"paste:",
+		"performClose:",
+		"arrangeInFront:",
+	} {
+		if !strings.Contains(m, `selector="`+selector+`"`) {
+			t.Errorf("markup does not contain selector %v", selector)
+		}
+	}
+
+	order := []string{
+		`label="Hello &amp; Co"`,
+		`label="About Hello &amp; Co"`,
+		b.AppItems,
+		`label="Quit Hello &amp; Co"`,
+		b.FileMenus,
+		`label="Edit"`,
+		`selector="selectAll:"`,
+		b.EditItems,
+		b.Menus,
+		`label="Window" role="window"`,
+		`selector="performClose:"`,
+		b.WindowItems,
+		`selector="arrangeInFront:"`,
+		`label="Help" role="help"`,
+		`selector="showHelp:"`,
+	}
+	prev := -1
+	for _, s := range order {
+		i := strings.Index(m, s)
+		if i <= prev {
+			t.Fatalf("%s is not in place:\n%s", s, m)
+		}
+		prev = i
+	}
+}
+
+func TestStandardMenuBarDefaults(t *testing.T) {
+	name := filepath.Base(os.Args[0])
+	if n := driver.AppName(); n != name {
+		t.Fatalf("app name is %q, want %q", n, name)
+	}
+
+	m := StandardMenuBar{HelpItems: `<menuitem label="Guide" />`}.Markup()
+	if !strings.Contains(m, `label="Quit `+name+`"`) {
+		t.Errorf("markup does not use the app name:\n%s", m)
+	}
+	if strings.Contains(m, "showHelp:") {
+		t.Errorf("help items did not replace the default one:\n%s", m)
+	}
+}
@@ -0,0 +1,133 @@
+package mac
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NavigationType describes what triggered a navigation.
+type NavigationType int
+
+// Constants that define the navigation types.
+const (
+	// NavigationOther is a navigation triggered by something else than the
+	// cases below, such as a script setting window.location.
+	NavigationOther NavigationType = iota
+
+	// NavigationLinkActivated is a navigation triggered by a click on a link.
+	NavigationLinkActivated
+
+	// NavigationFormSubmitted is a navigation triggered by a form submission.
+	NavigationFormSubmitted
+
+	// NavigationBackForward is a navigation through the webview history.
+	NavigationBackForward
+
+	// NavigationReload is a page reload.
+	NavigationReload
+
+	// NavigationFormResubmitted is a navigation triggered by a form
+	// resubmission.
+	NavigationFormResubmitted
+)
+
+func (t NavigationType) String() string {
+	switch t {
+	case NavigationLinkActivated:
+		return "link activated"
+
+	case NavigationFormSubmitted:
+		return "form submitted"
+
+	case NavigationBackForward:
+		return "back forward"
+
+	case NavigationReload:
+		return "reload"
+
+	case NavigationFormResubmitted:
+		return "form resubmitted"
+
+	default:
+		return "other"
+	}
+}
+
+// navigationTypeFromWK converts a WKNavigationType value.
+func navigationTypeFromWK(t int) NavigationType {
+	switch t {
+	case 0:
+		return NavigationLinkActivated
+
+	case 1:
+		return NavigationFormSubmitted
+
+	case 2:
+		return NavigationBackForward
+
+	case 3:
+		return NavigationReload
+
+	case 4:
+		return NavigationFormResubmitted
+
+	default:
+		return NavigationOther
+	}
+}
+
+// Navigation describes a navigation requested by a webview.
+type Navigation struct {
+	URL  *url.URL
+	Type NavigationType
+}
+
+// NavigationPolicy is the decision taken for a navigation.
+type NavigationPolicy int
+
+// Constants that define the navigation policies.
+const (
+	// NavigationDeny cancels the navigation.
+	NavigationDeny NavigationPolicy = iota
+
+	// NavigationAllow loads the URL in the webview.
+	NavigationAllow
+
+	// NavigationOpenExternal opens the URL with the default app of the user.
+	NavigationOpenExternal
+
+	// NavigationRoute mounts the component targeted by the URL in the window.
+	NavigationRoute
+)
+
+func (p NavigationPolicy) String() string {
+	switch p {
+	case NavigationAllow:
+		return "allow"
+
+	case NavigationOpenExternal:
+		return "open external"
+
+	case NavigationRoute:
+		return "route"
+
+	default:
+		return "deny"
+	}
+}
+
+// DefaultNavigationPolicy is the policy of the windows without a navigation
+// handler. It routes component URLs, opens the URLs with a scheme listed in
+// ExternalSchemes externally, and denies the others.
+func (d *Driver) DefaultNavigationPolicy(n Navigation) NavigationPolicy {
+	if n.URL.Scheme == "component" {
+		return NavigationRoute
+	}
+
+	for _, scheme := range d.ExternalSchemes {
+		if strings.EqualFold(n.URL.Scheme, scheme) {
+			return NavigationOpenExternal
+		}
+	}
+	return NavigationDeny
+}
@@ -0,0 +1,90 @@
+package mac
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+func TestDefaultNavigationPolicy(t *testing.T) {
+	tests := []struct {
+		rawurl   string
+		expected NavigationPolicy
+	}{
+		{rawurl: "component://Hello", expected: NavigationRoute},
+		{rawurl: "https://github.com", expected: NavigationOpenExternal},
+		{rawurl: "HTTP://github.com", expected: NavigationOpenExternal},
+		{rawurl: "mailto:maxence@example.com", expected: NavigationOpenExternal},
+		{rawurl: "file:///etc/passwd", expected: NavigationDeny},
+		{rawurl: "javascript:alert(42)", expected: NavigationDeny},
+	}
+
+	for _, test := range tests {
+		URL, err := url.Parse(test.rawurl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		policy := driver.DefaultNavigationPolicy(Navigation{URL: URL})
+		if policy != test.expected {
+			t.Errorf("%v: policy should be %v: %v", test.rawurl, test.expected, policy)
+		}
+	}
+}
+
+func TestWindowNavigationPolicy(t *testing.T) {
+	var navs []Navigation
+
+	win, err := newWindow(Window{
+		OnNavigate: func(n Navigation) NavigationPolicy {
+			navs = append(navs, n)
+
+			switch n.URL.Host {
+			case "allowed.com":
+				return NavigationAllow
+
+			case "external.com":
+				return NavigationOpenExternal
+
+			default:
+				return NavigationDeny
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer win.Close()
+
+	if !handleWindowWebviewNavigate(win.ID(), "https://allowed.com", NavigationLinkActivated) {
+		t.Error("navigation should be allowed")
+	}
+	if handleWindowWebviewNavigate(win.ID(), "https://external.com", NavigationFormSubmitted) {
+		t.Error("navigation should be opened externally")
+	}
+	if handleWindowWebviewNavigate(win.ID(), "https://denied.com", NavigationOther) {
+		t.Error("navigation should be denied")
+	}
+
+	calls := fakeNative().Calls("OpenURL")
+	if len(calls) == 0 || calls[len(calls)-1].Args[0] != "https://external.com" {
+		t.Error("url should be opened externally")
+	}
+	if len(navs) != 3 || navs[1].Type != NavigationFormSubmitted {
+		t.Errorf("navigations should be reported with their type: %+v", navs)
+	}
+}
+
+func TestWindowDefaultNavigationPolicy(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	before := len(fakeNative().Calls("OpenURL"))
+	if handleWindowWebviewNavigate(win.ID(), "file:///etc/passwd", NavigationLinkActivated) {
+		t.Error("navigation should be denied")
+	}
+	if len(fakeNative().Calls("OpenURL")) != before {
+		t.Error("denied url should not be opened")
+	}
+}
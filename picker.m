@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "picker.h"
 #include "_cgo_export.h"
 #include "driver.h"
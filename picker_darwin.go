@@ -0,0 +1,38 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#include "picker.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/murlokswarm/log"
+)
+
+func (b cocoaBackend) NewFilePicker(p filePickerSpec) {
+	cpicker := C.FilePicker__{
+		ID:                cString(p.ID),
+		MultipleSelection: boolToBOOL(p.MultipleSelection),
+		NoDir:             boolToBOOL(p.NoDir),
+		NoFile:            boolToBOOL(p.NoFile),
+	}
+	defer free(unsafe.Pointer(cpicker.ID))
+
+	C.Picker_NewFilePicker(cpicker)
+}
+
+//export onFilePickerClosed
+func onFilePickerClosed(cid *C.char, filenamesJSON *C.char) {
+	var filenames []string
+	data := []byte(C.GoString(filenamesJSON))
+	if err := json.Unmarshal(data, &filenames); err != nil {
+		log.Error(err)
+		return
+	}
+	handleFilePickerClosed(goUUID(cid), filenames)
+}
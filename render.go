@@ -0,0 +1,169 @@
+package mac
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/murlokswarm/log"
+	"github.com/murlokswarm/markup"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+)
+
+// renderBatch accumulates the syncs of a window until the functions queued on
+// the UI goroutine before its flush have run. They are then sent in a single
+// script.
+type renderBatch struct {
+	scheduled bool
+	full      []*markup.Node
+	attrs     []*markup.Node
+	attrMaps  map[uuid.UUID]markup.AttributeMap
+}
+
+// add queues s in the batch. Attribute syncs of the same node are merged.
+func (b *renderBatch) add(s markup.Sync) {
+	if s.Scope == markup.FullSync {
+		b.full = append(b.full, s.Node)
+		return
+	}
+
+	if b.attrMaps == nil {
+		b.attrMaps = make(map[uuid.UUID]markup.AttributeMap)
+	}
+
+	attrs, ok := b.attrMaps[s.Node.ID]
+	if !ok {
+		attrs = make(markup.AttributeMap, len(s.Attributes))
+		b.attrMaps[s.Node.ID] = attrs
+		b.attrs = append(b.attrs, s.Node)
+	}
+	for name, val := range s.Attributes {
+		attrs[name] = val
+	}
+}
+
+func (b *renderBatch) empty() bool {
+	return len(b.full) == 0 && len(b.attrs) == 0
+}
+
+// script returns the javascript that applies the batch. Syncs of nodes that
+// are fully rendered by the sync of themselves or of an ancestor are dropped,
+// as well as the syncs of the nodes dismounted since they were queued.
+// The markup of the fully rendered nodes is read at this point, which makes it
+// reflect every change made since the syncs were queued.
+func (b *renderBatch) script() string {
+	fullIDs := make(map[uuid.UUID]bool, len(b.full))
+	for _, n := range b.full {
+		if !detached(n) {
+			fullIDs[n.ID] = true
+		}
+	}
+
+	var calls []string
+
+	for _, n := range b.full {
+		if fullIDs[n.ID] && !renderedByAncestor(n, fullIDs) {
+			html := strconv.Quote(n.Markup())
+			calls = append(calls, fmt.Sprintf(`RenderFull("%v", %v);`, n.ID, html))
+
+			// Prevents duplicate full syncs of the same node.
+			fullIDs[n.ID] = false
+		}
+	}
+
+	for _, n := range b.attrs {
+		if _, ok := fullIDs[n.ID]; ok || renderedByAncestor(n, fullIDs) || detached(n) {
+			continue
+		}
+
+		d, err := json.Marshal(b.attrMaps[n.ID])
+		if err != nil {
+			log.Panic(errors.Wrap(err, "rendering attributes failed"))
+		}
+		calls = append(calls, fmt.Sprintf(`RenderAttributes("%v", %v);`, n.ID, string(d)))
+	}
+	return strings.Join(calls, "\n")
+}
+
+func renderedByAncestor(n *markup.Node, fullIDs map[uuid.UUID]bool) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if _, ok := fullIDs[p.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// detached reports whether n has been dismounted: a render that replaces a
+// node removes it from the children of its parent.
+func detached(n *markup.Node) bool {
+	for ; n.Parent != nil; n = n.Parent {
+		if !isChildNode(n.Parent, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func isChildNode(parent, n *markup.Node) bool {
+	if parent.Type == markup.ComponentNode {
+		return markup.Root(parent.Component) == n
+	}
+
+	for _, child := range parent.Children {
+		if child == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Render queues s. The queued syncs are rendered by a flush queued on the UI
+// goroutine without blocking it, which batches the syncs made until the
+// functions already queued have run.
+// Syncs with event handlers that are not bound to a component are dropped when
+// the window has a strict Content-Security-Policy.
+func (w *window) Render(s markup.Sync) {
+	if w.config.StrictCSP {
+		if err := validateSync(s); err != nil {
+			log.Error(errors.Wrap(err, "rendering failed"))
+			return
+		}
+	}
+
+	w.renderMutex.Lock()
+	w.renderBatch.add(s)
+	schedule := !w.renderBatch.scheduled
+	w.renderBatch.scheduled = true
+	w.renderMutex.Unlock()
+
+	if schedule {
+		postUI(w.flushRender)
+	}
+}
+
+// flushRender sends the queued syncs to the webview in a single script.
+func (w *window) flushRender() {
+	w.renderMutex.Lock()
+	batch := w.renderBatch
+	w.renderBatch = renderBatch{}
+	w.renderMutex.Unlock()
+
+	if batch.empty() {
+		return
+	}
+
+	if script := batch.script(); len(script) != 0 {
+		evalJS(w.id, w.ptr, script, logJSError("rendering failed"))
+	}
+}
+
+// discardRender drops the queued syncs. It is used when the nodes they refer
+// to are no longer displayed.
+func (w *window) discardRender() {
+	w.renderMutex.Lock()
+	w.renderBatch = renderBatch{}
+	w.renderMutex.Unlock()
+}
@@ -0,0 +1,189 @@
+package mac
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
+)
+
+func newTestList(rows int) (list *markup.Node, items []*markup.Node) {
+	list = &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "ul",
+	}
+
+	for i := 0; i < rows; i++ {
+		item := &markup.Node{
+			ID:     uuid.NewV1(),
+			Tag:    "li",
+			Parent: list,
+		}
+		list.Children = append(list.Children, item)
+		items = append(items, item)
+	}
+	return
+}
+
+func attrSync(n *markup.Node, name, value string) markup.Sync {
+	return markup.Sync{
+		Scope:      markup.AttrSync,
+		Node:       n,
+		Attributes: markup.AttributeMap{name: value},
+	}
+}
+
+func TestRenderBatch(t *testing.T) {
+	list, items := newTestList(3)
+	other := &markup.Node{ID: uuid.NewV1(), Tag: "p"}
+
+	b := renderBatch{}
+	b.add(attrSync(items[0], "class", "selected"))
+	b.add(markup.Sync{Scope: markup.FullSync, Node: list})
+	b.add(markup.Sync{Scope: markup.FullSync, Node: items[1]})
+	b.add(markup.Sync{Scope: markup.FullSync, Node: list})
+	b.add(attrSync(other, "class", "hidden"))
+	b.add(attrSync(other, "title", "hello"))
+
+	script := b.script()
+
+	if n := strings.Count(script, "RenderFull("); n != 1 {
+		t.Errorf("script should have 1 full render: %v", n)
+	}
+	if !strings.Contains(script, list.ID.String()) {
+		t.Error("script should render the list")
+	}
+	if strings.Contains(script, items[0].ID.String()) || strings.Contains(script, items[1].ID.String()) {
+		t.Error("script should not render the list items")
+	}
+	if n := strings.Count(script, "RenderAttributes("); n != 1 {
+		t.Errorf("script should have 1 attributes render: %v", n)
+	}
+	if !strings.Contains(script, `{"class":"hidden","title":"hello"}`) {
+		t.Error("attributes should be merged")
+	}
+}
+
+func TestRenderBatchDetached(t *testing.T) {
+	list, items := newTestList(3)
+
+	b := renderBatch{}
+	b.add(markup.Sync{Scope: markup.FullSync, Node: items[1]})
+	b.add(attrSync(items[2], "class", "selected"))
+	b.add(attrSync(items[0], "class", "selected"))
+
+	// A render of the list replaces its last items.
+	list.Children = list.Children[:1]
+
+	script := b.script()
+	if strings.Contains(script, items[1].ID.String()) || strings.Contains(script, items[2].ID.String()) {
+		t.Errorf("script should not render the dismounted items:\n%s", script)
+	}
+	if !strings.Contains(script, items[0].ID.String()) {
+		t.Errorf("script should render the mounted item:\n%s", script)
+	}
+}
+
+func TestWindowRender(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	_, items := newTestList(10)
+
+	app.UIChan <- func() {
+		for _, item := range items {
+			win.Render(attrSync(item, "class", "selected"))
+		}
+	}
+
+	waitUI()
+	fakeNative().flush()
+
+	if n := len(fakeNative().scripts(win.ptr)); n != 1 {
+		t.Errorf("syncs should be sent in 1 script: %v", n)
+	}
+}
+
+func TestWindowRenderFullUIChan(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	_, items := newTestList(1)
+	rendered := make(chan struct{})
+
+	app.UIChan <- func() {
+		for len(app.UIChan) < cap(app.UIChan) {
+			app.UIChan <- func() {}
+		}
+		win.Render(attrSync(items[0], "class", "selected"))
+		close(rendered)
+	}
+
+	select {
+	case <-rendered:
+	case <-time.After(time.Second):
+		t.Fatal("render should not block the UI goroutine")
+	}
+
+	// The flush is queued once the UI goroutine has room for it.
+	deadline := time.Now().Add(time.Second)
+	for len(fakeNative().scripts(win.ptr)) == 0 && time.Now().Before(deadline) {
+		waitUI()
+		fakeNative().flush()
+	}
+
+	if n := len(fakeNative().scripts(win.ptr)); n != 1 {
+		t.Errorf("sync should be sent in 1 script: %v", n)
+	}
+}
+
+// benchmarkRender renders an attribute change of each row of a list and
+// reports the number of native script evaluations. Batched renders happen in
+// a single UI goroutine tick, unbatched ones in a tick per row.
+func benchmarkRender(b *testing.B, rows int, batched bool) {
+	win := newTestWindow(b, app.Window{})
+	_, items := newTestList(rows)
+
+	b.ResetTimer()
+	before := len(fakeNative().Calls("WindowEvalJS"))
+
+	for n := 0; n < b.N; n++ {
+		if batched {
+			app.UIChan <- func() {
+				for i, item := range items {
+					win.Render(attrSync(item, "value", strconv.Itoa(n+i)))
+				}
+			}
+			waitUI()
+			continue
+		}
+
+		for i, item := range items {
+			s := attrSync(item, "value", strconv.Itoa(n+i))
+			app.UIChan <- func() {
+				win.Render(s)
+			}
+			waitUI()
+		}
+	}
+
+	fakeNative().flush()
+	b.StopTimer()
+
+	calls := len(fakeNative().Calls("WindowEvalJS")) - before
+	if want := b.N; batched && calls != want {
+		b.Errorf("batched renders should evaluate 1 script per op: %v calls for %v ops", calls, want)
+	}
+	if want := b.N * rows; !batched && calls != want {
+		b.Errorf("unbatched renders should evaluate 1 script per row: %v calls for %v ops", calls, b.N)
+	}
+	b.ReportMetric(float64(calls)/float64(b.N), "evals/op")
+}
+
+func BenchmarkRenderUnbatched500(b *testing.B) {
+	benchmarkRender(b, 500, false)
+}
+
+func BenchmarkRenderBatched500(b *testing.B) {
+	benchmarkRender(b, 500, true)
+}
@@ -0,0 +1,334 @@
+package mac
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/log"
+	"github.com/murlokswarm/markup"
+	"github.com/pkg/errors"
+)
+
+// Router builds the components targeted by URLs.
+// Routes are patterns such as "component://document/{id}". They match the host
+// and the path of URLs. The scheme is ignored so that deep links such as
+// "myapp://document/42" resolve the same way as "component://document/42".
+//
+// The exported fields of a routed component are populated from the path
+// parameters and the query values whose names match, case insensitively, the
+// field names or their `route` tags. Fields are converted to strings, bools,
+// numbers, slices of them, and encoding.TextUnmarshaler implementations.
+type Router struct {
+	mutex    sync.Mutex
+	routes   []route
+	notFound reflect.Type
+}
+
+type route struct {
+	pattern   string
+	segments  []string
+	component reflect.Type
+}
+
+func newRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers the route described by pattern. c is a pointer to a struct
+// component; each resolution creates a new instance of its type.
+func (r *Router) Handle(pattern string, c app.Componer) error {
+	t, err := routeComponentType(c)
+	if err != nil {
+		return errors.Wrapf(err, "registering route %v failed", pattern)
+	}
+
+	segments := routeSegments(pattern)
+	if len(segments) == 0 || isRouteParam(segments[0]) {
+		return errors.Errorf("registering route %v failed: pattern has no host", pattern)
+	}
+
+	r.mutex.Lock()
+	r.routes = append(r.routes, route{
+		pattern:   pattern,
+		segments:  segments,
+		component: t,
+	})
+	r.mutex.Unlock()
+	return nil
+}
+
+// NotFound sets the component built for the URLs that match no route. Its
+// fields are populated from the query values.
+func (r *Router) NotFound(c app.Componer) error {
+	t, err := routeComponentType(c)
+	if err != nil {
+		return errors.Wrap(err, "registering not found component failed")
+	}
+
+	r.mutex.Lock()
+	r.notFound = t
+	r.mutex.Unlock()
+	return nil
+}
+
+// Resolve builds the component targeted by URL.
+// URLs that match no route are resolved with the component registered under
+// the URL host, then with the not found component. It returns a
+// *RouteNotFoundError when none is available.
+func (r *Router) Resolve(URL *url.URL) (app.Componer, error) {
+	if c, ok, err := r.resolveRoute(URL); ok || err != nil {
+		return c, err
+	}
+
+	r.mutex.Lock()
+	notFound := r.notFound
+	r.mutex.Unlock()
+
+	if len(URL.Host) != 0 {
+		if c, err := markup.New(URL.Host); err == nil {
+			return c, nil
+		}
+	}
+
+	if notFound != nil {
+		c := reflect.New(notFound.Elem())
+		if err := populateComponent(c, URL.Query(), nil); err != nil {
+			return nil, errors.Wrapf(err, "resolving %v with not found component failed", URL)
+		}
+		return c.Interface().(app.Componer), nil
+	}
+	return nil, &RouteNotFoundError{URL: URL.String()}
+}
+
+// resolveRoute builds the component of the first route that matches URL. It
+// reports whether a route matched.
+func (r *Router) resolveRoute(URL *url.URL) (c app.Componer, ok bool, err error) {
+	segments := append([]string{URL.Host}, routeSegments(URL.Path)...)
+
+	r.mutex.Lock()
+	routes := r.routes
+	r.mutex.Unlock()
+
+	for _, rt := range routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+
+		v := reflect.New(rt.component.Elem())
+		if err := populateComponent(v, URL.Query(), params); err != nil {
+			return nil, true, errors.Wrapf(err, "resolving %v with route %v failed", URL, rt.pattern)
+		}
+		return v.Interface().(app.Componer), true, nil
+	}
+	return nil, false, nil
+}
+
+func (rt route) match(segments []string) (params map[string]string, ok bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params = make(map[string]string)
+	for i, s := range rt.segments {
+		if isRouteParam(s) {
+			params[s[1:len(s)-1]] = segments[i]
+			continue
+		}
+
+		if i == 0 && !strings.EqualFold(s, segments[i]) {
+			return nil, false
+		}
+		if i != 0 && s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func routeComponentType(c app.Componer) (reflect.Type, error) {
+	t := reflect.TypeOf(c)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, errors.Errorf("component %T is not a pointer to a struct", c)
+	}
+	return t, nil
+}
+
+// routeSegments splits a pattern or an URL path, without its scheme, into
+// segments.
+func routeSegments(s string) []string {
+	if i := strings.Index(s, "://"); i != -1 {
+		s = s[i+3:]
+	}
+
+	s = strings.Trim(s, "/")
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(s, "/")
+}
+
+func isRouteParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// populateComponent sets the fields of the component pointed by c with the
+// query values, then with the path parameters.
+func populateComponent(c reflect.Value, query url.Values, params map[string]string) error {
+	v := c.Elem()
+
+	for name, values := range query {
+		if err := setRouteField(v, name, values); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range params {
+		if err := setRouteField(v, name, []string{value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setRouteField(v reflect.Value, name string, values []string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) != 0 {
+			continue
+		}
+
+		key := f.Tag.Get("route")
+		if key == "-" {
+			continue
+		}
+		if len(key) == 0 {
+			key = f.Name
+		}
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+
+		if err := setRouteValue(v.Field(i), values); err != nil {
+			return errors.Wrapf(err, "setting field %v with %q failed", f.Name, values)
+		}
+		return nil
+	}
+	return nil
+}
+
+func setRouteValue(f reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if _, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok || f.Kind() != reflect.Slice {
+		return setRouteString(f, values[0])
+	}
+
+	s := reflect.MakeSlice(f.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setRouteString(s.Index(i), value); err != nil {
+			return err
+		}
+	}
+	f.Set(s)
+	return nil
+}
+
+func setRouteString(f reflect.Value, s string) error {
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+
+	case reflect.Ptr:
+		ptr := reflect.New(f.Type().Elem())
+		if err := setRouteString(ptr.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(ptr)
+
+	default:
+		return errors.Errorf("type %v is not supported", f.Type())
+	}
+	return nil
+}
+
+// routeURLOpen mounts the component targeted by an URL the app is asked to
+// open in the focused window, or in the first window, when Driver.RouteURLOpen
+// accepts it. URLs with the component scheme are resolved like the webview
+// navigations, with the not found component as fallback. Other URLs are only
+// mounted when they match a route. It must be called on the UI goroutine.
+func routeURLOpen(URL *url.URL) {
+	if route := driver.RouteURLOpen; route == nil || !route(*URL) {
+		return
+	}
+
+	var c app.Componer
+	var err error
+
+	if URL.Scheme == "component" {
+		c, err = driver.Router.Resolve(URL)
+	} else {
+		var ok bool
+		if c, ok, err = driver.Router.resolveRoute(URL); err == nil && !ok {
+			return
+		}
+	}
+	if err != nil {
+		log.Error(errors.Wrapf(err, "routing %v failed", URL))
+		return
+	}
+
+	win, ok := driver.FocusedWindow()
+	if !ok {
+		windows := driver.Windows()
+		if len(windows) == 0 {
+			return
+		}
+		win = windows[0]
+	}
+
+	if err = win.(*window).mountRouted(c, URL); err != nil {
+		log.Error(err)
+	}
+}
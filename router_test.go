@@ -0,0 +1,187 @@
+package mac
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+type RoutedDocument struct {
+	ID     int
+	Tab    string
+	Tags   []string
+	Ratio  float64
+	Pinned *bool
+	Search string `route:"q"`
+	secret string
+}
+
+func (d *RoutedDocument) Render() string {
+	return `<div>document</div>`
+}
+
+type RoutedText string
+
+func (t RoutedText) Render() string {
+	return `<p>` + string(t) + `</p>`
+}
+
+type RoutedNotFound struct {
+	From string
+}
+
+func (n *RoutedNotFound) Render() string {
+	return `<div>not found</div>`
+}
+
+func resolve(t *testing.T, r *Router, rawurl string) (interface{}, error) {
+	URL, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Resolve(URL)
+}
+
+func TestRouter(t *testing.T) {
+	r := newRouter()
+	if err := r.Handle("component://document/{id}", &RoutedDocument{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := resolve(t, r, "component://document/42?tab=info&tags=a&tags=b&ratio=0.5&pinned=true&q=hello&secret=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, ok := c.(*RoutedDocument)
+	if !ok {
+		t.Fatalf("component should be a *RoutedDocument: %T", c)
+	}
+
+	pinned := true
+	expected := &RoutedDocument{
+		ID:     42,
+		Tab:    "info",
+		Tags:   []string{"a", "b"},
+		Ratio:  0.5,
+		Pinned: &pinned,
+		Search: "hello",
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("component should be %+v: %+v", expected, doc)
+	}
+
+	c, err = resolve(t, r, "myapp://Document/21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc := c.(*RoutedDocument); doc.ID != 21 {
+		t.Errorf("deep link should be routed with id 21: %v", doc.ID)
+	}
+}
+
+func TestRouterErrors(t *testing.T) {
+	r := newRouter()
+
+	if err := r.Handle("component://{name}", &RoutedDocument{}); err == nil {
+		t.Error("pattern without host should be rejected")
+	}
+	if err := r.Handle("component://text", RoutedText("hello")); err == nil {
+		t.Error("component that is not a pointer to a struct should be rejected")
+	}
+	if err := r.Handle("component://document/{id}", &RoutedDocument{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := resolve(t, r, "component://document/abc"); err == nil {
+		t.Error("invalid parameter should be reported")
+	}
+
+	_, err := resolve(t, r, "component://unknown/42")
+	if _, ok := err.(*RouteNotFoundError); !ok {
+		t.Errorf("err should be a *RouteNotFoundError: %v", err)
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	r := newRouter()
+	if err := r.NotFound(&RoutedNotFound{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := resolve(t, r, "component://unknown/42?from=menu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notFound, ok := c.(*RoutedNotFound)
+	if !ok || notFound.From != "menu" {
+		t.Errorf("not found component should be built with from=menu: %+v", c)
+	}
+}
+
+func TestRouteURLOpen(t *testing.T) {
+	defer func(r *Router) { driver.Router = r }(driver.Router)
+	driver.Router = newRouter()
+	driver.Router.Handle("component://document/{id}", &RoutedDocument{})
+	driver.Router.NotFound(&RoutedNotFound{})
+
+	defer func() {
+		driver.RouteURLOpen = nil
+		app.OnURLOpen = nil
+	}()
+
+	var opened []string
+	app.OnURLOpen = func(URL url.URL) {
+		opened = append(opened, URL.String())
+	}
+
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+	driver.setFocusedWindow(win, true)
+
+	// The app handles the URLs by default.
+	handleURLOpen("myapp://document/42")
+	waitUI()
+
+	if c := win.Component(); c != nil {
+		t.Fatalf("url should be left to the app: %T", c)
+	}
+	if len(opened) != 1 || opened[0] != "myapp://document/42" {
+		t.Fatalf("app.OnURLOpen should be called: %v", opened)
+	}
+
+	driver.RouteURLOpen = func(URL url.URL) bool {
+		return URL.Path != "/ignored"
+	}
+
+	handleURLOpen("myapp://document/ignored")
+	handleURLOpen("myapp://anything")
+	waitUI()
+
+	if c := win.Component(); c != nil {
+		t.Fatalf("url should not be mounted: %T", c)
+	}
+	if entries, _ := win.History(); len(entries) != 0 {
+		t.Errorf("unmounted urls should not be in the history: %v", entries)
+	}
+
+	handleURLOpen("myapp://document/42")
+	waitUI()
+
+	if doc, ok := win.Component().(*RoutedDocument); !ok || doc.ID != 42 {
+		t.Errorf("document 42 should be mounted: %+v", win.Component())
+	}
+
+	handleURLOpen("component://unknown?from=link")
+	waitUI()
+
+	if c, ok := win.Component().(*RoutedNotFound); !ok || c.From != "link" {
+		t.Errorf("not found component should be mounted: %+v", win.Component())
+	}
+	if len(opened) != 5 {
+		t.Errorf("app.OnURLOpen should be called for every url: %v", opened)
+	}
+}
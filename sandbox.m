@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #import "sandbox.h"
 #import <Security/SecRequirement.h>
 #import <objc/runtime.h>
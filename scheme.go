@@ -0,0 +1,96 @@
+package mac
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/murlokswarm/log"
+	"github.com/pkg/errors"
+)
+
+const defaultResourceScheme = "murlok"
+
+// resourcesFS returns the filesystem that holds the resources served to the
+// webviews.
+func (d *Driver) resourcesFS() fs.FS {
+	if d.ResourcesFS != nil {
+		return d.ResourcesFS
+	}
+	return os.DirFS(d.Resources())
+}
+
+// resourcesBaseURL returns the URL that the resources paths are relative to in
+// the webviews.
+func (d *Driver) resourcesBaseURL() string {
+	return d.ResourceScheme + "://resources/"
+}
+
+// resourceFilenames returns the paths of the files with extension ext in the
+// directory dir of the resources filesystem.
+func resourceFilenames(fsys fs.FS, dir string, ext string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Error(errors.Wrapf(err, "listing resources in %v failed", dir))
+		}
+		return nil
+	}
+
+	var filenames []string
+	for _, e := range entries {
+		if !e.IsDir() && path.Ext(e.Name()) == ext {
+			filenames = append(filenames, path.Join(dir, e.Name()))
+		}
+	}
+	return filenames
+}
+
+// readResource returns the content and the MIME type of the resource targeted
+// by rawurl. It returns a *MissingResourceError when the resource does not
+// exist.
+func readResource(rawurl string) (data []byte, mimeType string, err error) {
+	URL, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "reading resource %v failed", rawurl)
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+URL.Path), "/")
+	if !fs.ValidPath(name) || name == "." {
+		return nil, "", &MissingResourceError{Name: URL.Path}
+	}
+
+	data, err = fs.ReadFile(driver.resourcesFS(), name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", &MissingResourceError{Name: name}
+	}
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "reading resource %v failed", name)
+	}
+
+	if mimeType = mime.TypeByExtension(path.Ext(name)); len(mimeType) == 0 {
+		mimeType = http.DetectContentType(data)
+	}
+	return data, mimeType, nil
+}
+
+// handleResourceRequest returns the response to a request made by a webview
+// with the resources scheme.
+func handleResourceRequest(rawurl string) (data []byte, mimeType string, status int) {
+	data, mimeType, err := readResource(rawurl)
+	if err == nil {
+		return data, mimeType, http.StatusOK
+	}
+
+	log.Error(err)
+	mimeType = "text/plain; charset=utf-8"
+
+	if _, ok := err.(*MissingResourceError); ok {
+		return []byte(err.Error()), mimeType, http.StatusNotFound
+	}
+	return []byte(err.Error()), mimeType, http.StatusInternalServerError
+}
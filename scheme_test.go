@@ -0,0 +1,99 @@
+package mac
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResourceFilenames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/main.css":     {Data: []byte("body {}")},
+		"css/theme.css":    {Data: []byte("h1 {}")},
+		"css/readme.txt":   {Data: []byte("hello")},
+		"css/vendor/x.css": {Data: []byte("p {}")},
+		"js/main.js":       {Data: []byte("var x;")},
+	}
+
+	expected := []string{"css/main.css", "css/theme.css"}
+	if css := resourceFilenames(fsys, "css", ".css"); !reflect.DeepEqual(css, expected) {
+		t.Errorf("css should be %v: %v", expected, css)
+	}
+
+	expected = []string{"js/main.js"}
+	if js := resourceFilenames(fsys, "js", ".js"); !reflect.DeepEqual(js, expected) {
+		t.Errorf("js should be %v: %v", expected, js)
+	}
+
+	if images := resourceFilenames(fsys, "images", ".png"); len(images) != 0 {
+		t.Errorf("images should be empty: %v", images)
+	}
+}
+
+func TestHandleResourceRequest(t *testing.T) {
+	defer func() {
+		driver.ResourcesFS = nil
+	}()
+
+	driver.ResourcesFS = fstest.MapFS{
+		"css/main.css": {Data: []byte("body {}")},
+		"images/logo":  {Data: []byte("\x89PNG\r\n\x1a\n")},
+	}
+
+	tests := []struct {
+		scenario string
+		url      string
+		mimeType string
+		status   int
+		data     string
+	}{
+		{
+			scenario: "css file",
+			url:      "murlok://resources/css/main.css",
+			mimeType: "text/css; charset=utf-8",
+			status:   http.StatusOK,
+			data:     "body {}",
+		},
+		{
+			scenario: "file without extension",
+			url:      "murlok://resources/images/logo",
+			mimeType: "image/png",
+			status:   http.StatusOK,
+			data:     "\x89PNG\r\n\x1a\n",
+		},
+		{
+			scenario: "missing file",
+			url:      "murlok://resources/css/missing.css",
+			status:   http.StatusNotFound,
+		},
+		{
+			scenario: "path escaping the filesystem",
+			url:      "murlok://resources/../../etc/passwd",
+			status:   http.StatusNotFound,
+		},
+		{
+			scenario: "directory",
+			url:      "murlok://resources/",
+			status:   http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		data, mimeType, status := handleResourceRequest(test.url)
+		if status != test.status {
+			t.Errorf("%v: status should be %v: %v", test.scenario, test.status, status)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+
+		if mimeType != test.mimeType {
+			t.Errorf("%v: mime type should be %v: %v", test.scenario, test.mimeType, mimeType)
+		}
+		if string(data) != test.data {
+			t.Errorf("%v: data should be %q: %q", test.scenario, test.data, data)
+		}
+	}
+}
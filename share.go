@@ -1,13 +1,8 @@
 package mac
 
-/*
-#include "share.h"
-*/
-import "C"
 import (
 	"fmt"
 	"net/url"
-	"unsafe"
 
 	"github.com/murlokswarm/app"
 	"github.com/satori/go.uuid"
@@ -18,21 +13,15 @@ type share struct {
 }
 
 func newShare(s app.Share) share {
-	var cvalue *C.char
-	defer free(unsafe.Pointer(cvalue))
-
 	switch v := s.Value.(type) {
 	case url.URL:
-		cvalue = cString(v.String())
-		C.Share_URL(cvalue)
+		native.ShareURL(v.String())
 
 	case *url.URL:
-		cvalue = cString(v.String())
-		C.Share_URL(cvalue)
+		native.ShareURL(v.String())
 
 	default:
-		cvalue = cString(fmt.Sprint(v))
-		C.Share_Text(cvalue)
+		native.ShareText(fmt.Sprint(v))
 	}
 	return share{
 		id: uuid.NewV1(),
@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "share.h"
 #include "driver.h"
 #include "window.h"
@@ -0,0 +1,24 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#include "share.h"
+*/
+import "C"
+import "unsafe"
+
+func (b cocoaBackend) ShareText(v string) {
+	cvalue := cString(v)
+	defer free(unsafe.Pointer(cvalue))
+
+	C.Share_Text(cvalue)
+}
+
+func (b cocoaBackend) ShareURL(v string) {
+	cvalue := cString(v)
+	defer free(unsafe.Pointer(cvalue))
+
+	C.Share_URL(cvalue)
+}
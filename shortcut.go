@@ -0,0 +1,184 @@
+package mac
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ShortcutModifier is a set of modifier keys.
+type ShortcutModifier int
+
+// Constants that define the modifier keys. Their order is the order of the
+// canonical form.
+const (
+	ModifierCtrl ShortcutModifier = 1 << iota
+	ModifierAlt
+	ModifierShift
+	ModifierMeta
+	ModifierFn
+)
+
+var modifierNames = []struct {
+	modifier ShortcutModifier
+	name     string
+}{
+	{ModifierCtrl, "ctrl"},
+	{ModifierAlt, "alt"},
+	{ModifierShift, "shift"},
+	{ModifierMeta, "meta"},
+	{ModifierFn, "fn"},
+}
+
+var modifierAliases = map[string]ShortcutModifier{
+	"ctrl":     ModifierCtrl,
+	"control":  ModifierCtrl,
+	"alt":      ModifierAlt,
+	"opt":      ModifierAlt,
+	"option":   ModifierAlt,
+	"shift":    ModifierShift,
+	"meta":     ModifierMeta,
+	"cmd":      ModifierMeta,
+	"command":  ModifierMeta,
+	"fn":       ModifierFn,
+	"function": ModifierFn,
+}
+
+// namedKeys maps the key names to the characters Cocoa uses as key
+// equivalents.
+var namedKeys = map[string]string{
+	"up":            "\uf700",
+	"down":          "\uf701",
+	"left":          "\uf702",
+	"right":         "\uf703",
+	"delete":        "\u0008",
+	"forwarddelete": "\uf728",
+	"home":          "\uf729",
+	"end":           "\uf72b",
+	"pageup":        "\uf72c",
+	"pagedown":      "\uf72d",
+	"escape":        "\u001b",
+	"return":        "\r",
+	"enter":         "\u0003",
+	"tab":           "\t",
+	"space":         " ",
+	"plus":          "+",
+}
+
+var keyAliases = map[string]string{
+	"arrowup":    "up",
+	"arrowdown":  "down",
+	"arrowleft":  "left",
+	"arrowright": "right",
+	"backspace":  "delete",
+	"del":        "forwarddelete",
+	"esc":        "escape",
+	"pgup":       "pageup",
+	"pgdown":     "pagedown",
+	"+":          "plus",
+}
+
+func init() {
+	// F1 to F20 are mapped from NSF1FunctionKey.
+	for i := 1; i <= 20; i++ {
+		namedKeys[fmt.Sprintf("f%v", i)] = string(rune(0xf703 + i))
+	}
+}
+
+// Shortcut is a parsed keyboard shortcut.
+type Shortcut struct {
+	Modifiers ShortcutModifier
+
+	// Key is a lowercase character or the name of a key such as "f1",
+	// "left" or "delete".
+	Key string
+}
+
+// ParseShortcut parses a shortcut such as "cmd+shift+k" or "meta+f5".
+// Modifiers and key names are case insensitive, and modifiers accept the
+// aliases cmd, command, control, opt, option and function. An uppercase
+// letter key implies the shift modifier. It returns a *ShortcutError when s is
+// not a valid shortcut.
+func ParseShortcut(s string) (Shortcut, error) {
+	var sc Shortcut
+
+	parts := strings.Split(s, "+")
+
+	// A trailing "++" or a lone "+" designates the plus key.
+	if strings.HasSuffix(s, "++") || s == "+" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	for _, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if len(name) == 0 {
+			return Shortcut{}, &ShortcutError{Shortcut: s, Reason: "empty key"}
+		}
+
+		if m, ok := modifierAliases[name]; ok {
+			sc.Modifiers |= m
+			continue
+		}
+
+		if len(sc.Key) != 0 {
+			return Shortcut{}, &ShortcutError{
+				Shortcut: s,
+				Reason:   fmt.Sprintf("%q is neither a modifier nor the only key", strings.TrimSpace(part)),
+			}
+		}
+
+		key, modifiers, err := parseShortcutKey(strings.TrimSpace(part))
+		if err != nil {
+			return Shortcut{}, &ShortcutError{Shortcut: s, Reason: err.Error()}
+		}
+		sc.Key = key
+		sc.Modifiers |= modifiers
+	}
+
+	if len(sc.Key) == 0 {
+		return Shortcut{}, &ShortcutError{Shortcut: s, Reason: "no key"}
+	}
+	return sc, nil
+}
+
+func parseShortcutKey(part string) (key string, modifiers ShortcutModifier, err error) {
+	name := strings.ToLower(part)
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := namedKeys[name]; ok {
+		return name, 0, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(part)
+	if size != len(part) || !unicode.IsPrint(r) || unicode.IsSpace(r) {
+		return "", 0, errors.Errorf("%q is not a key", part)
+	}
+
+	if unicode.IsUpper(r) {
+		modifiers = ModifierShift
+	}
+	return string(unicode.ToLower(r)), modifiers, nil
+}
+
+// String returns the canonical form of the shortcut.
+func (s Shortcut) String() string {
+	var parts []string
+	for _, m := range modifierNames {
+		if s.Modifiers&m.modifier != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, s.Key), "+")
+}
+
+// keyEquivalent returns the character Cocoa uses for the shortcut key.
+func (s Shortcut) keyEquivalent() string {
+	if k, ok := namedKeys[s.Key]; ok {
+		return k
+	}
+	return s.Key
+}
@@ -0,0 +1,106 @@
+package mac
+
+import (
+	"testing"
+
+	"github.com/murlokswarm/app"
+	"github.com/murlokswarm/markup"
+	"github.com/satori/go.uuid"
+)
+
+func TestParseShortcut(t *testing.T) {
+	tests := []struct {
+		shortcut  string
+		canonical string
+		key       string
+		err       bool
+	}{
+		{shortcut: "meta+k", canonical: "meta+k", key: "k"},
+		{shortcut: "Shift+Cmd+K", canonical: "shift+meta+k", key: "k"},
+		{shortcut: "cmd+K", canonical: "shift+meta+k", key: "k"},
+		{shortcut: "option+control+delete", canonical: "ctrl+alt+delete", key: "\b"},
+		{shortcut: "meta+backspace", canonical: "meta+delete", key: "\b"},
+		{shortcut: "fn+f5", canonical: "fn+f5", key: "\uf708"},
+		{shortcut: "cmd+alt+Left", canonical: "alt+meta+left", key: "\uf702"},
+		{shortcut: "meta++", canonical: "meta+plus", key: "+"},
+		{shortcut: "meta+,", canonical: "meta+,", key: ","},
+		{shortcut: "+", canonical: "plus", key: "+"},
+		{shortcut: "cmd+shft+k", err: true},
+		{shortcut: "cmd+shift", err: true},
+		{shortcut: "cmd+a+b", err: true},
+		{shortcut: "cmd++k", err: true},
+		{shortcut: "", err: true},
+	}
+
+	for _, test := range tests {
+		sc, err := ParseShortcut(test.shortcut)
+		if test.err {
+			if _, ok := err.(*ShortcutError); !ok {
+				t.Errorf("%q: error should be a *ShortcutError: %v", test.shortcut, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.shortcut, err)
+			continue
+		}
+
+		if s := sc.String(); s != test.canonical {
+			t.Errorf("%q: canonical form should be %q: %q", test.shortcut, test.canonical, s)
+		}
+		if k := sc.keyEquivalent(); k != test.key {
+			t.Errorf("%q: key equivalent should be %q: %q", test.shortcut, test.key, k)
+		}
+	}
+}
+
+func TestMenuShortcuts(t *testing.T) {
+	m := newMenu(app.Menu{})
+
+	root := &markup.Node{
+		ID:  uuid.NewV1(),
+		Tag: "menu",
+	}
+
+	item := func(label, shortcut string) *markup.Node {
+		return &markup.Node{
+			ID:     uuid.NewV1(),
+			Tag:    "menuitem",
+			Parent: root,
+			Attributes: markup.AttributeMap{
+				"label":    label,
+				"shortcut": shortcut,
+			},
+		}
+	}
+
+	copyItem := item("Copy", "cmd+c")
+	if _, err := m.itemSpec(copyItem); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.itemSpec(copyItem); err != nil {
+		t.Fatal(err)
+	}
+	if other, conflict := m.claimShortcut(copyItem.ID, Shortcut{Modifiers: ModifierMeta, Key: "c"}, "Copy"); conflict {
+		t.Errorf("remounted item should not conflict with itself: %v", other)
+	}
+
+	clearID := uuid.NewV1()
+	if other, conflict := m.claimShortcut(clearID, Shortcut{Modifiers: ModifierMeta, Key: "c"}, "Clear"); !conflict || other != "Copy" {
+		t.Errorf("shortcut should conflict with Copy: %v %v", other, conflict)
+	}
+	if other, conflict := m.claimShortcut(clearID, Shortcut{Modifiers: ModifierMeta, Key: "c"}, "Clear"); conflict {
+		t.Errorf("conflict should only be reported when introduced: %v", other)
+	}
+	if _, conflict := m.claimShortcut(clearID, Shortcut{Modifiers: ModifierMeta, Key: "l"}, "Clear"); conflict {
+		t.Error("shortcut should not conflict")
+	}
+	if other, conflict := m.claimShortcut(clearID, Shortcut{Modifiers: ModifierMeta, Key: "c"}, "Clear"); !conflict || other != "Copy" {
+		t.Errorf("changed shortcut should conflict with Copy: %v %v", other, conflict)
+	}
+
+	_, err := m.itemSpec(item("Kill", "cmd+shft+k"))
+	if _, ok := err.(*ShortcutError); !ok {
+		t.Errorf("error should be a *ShortcutError: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package mac
+
+import (
+	"context"
+
+	"github.com/murlokswarm/app"
+)
+
+// State represents a stage of the driver lifecycle.
+type State int
+
+// Constants that define the driver lifecycle stages.
+const (
+	// StateIdle is the state of a driver that has not been run.
+	StateIdle State = iota
+
+	// StateLaunching is the state of a driver that has been run and waits for
+	// Cocoa to finish launching.
+	StateLaunching
+
+	// StateRunning is the state of a launched driver.
+	StateRunning
+
+	// StateTerminating is the state of a driver that has been asked to
+	// terminate and waits for the termination to be confirmed.
+	StateTerminating
+
+	// StateTerminated is the state of a driver whose app has been finalized.
+	StateTerminated
+)
+
+func (s State) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+
+	case StateLaunching:
+		return "launching"
+
+	case StateRunning:
+		return "running"
+
+	case StateTerminating:
+		return "terminating"
+
+	case StateTerminated:
+		return "terminated"
+
+	default:
+		return "unknown"
+	}
+}
+
+// StateEvent is emitted when the driver moves from a lifecycle state to
+// another.
+type StateEvent struct {
+	From State
+	To   State
+}
+
+// State returns the current lifecycle state of the driver.
+func (d *Driver) State() State {
+	d.stateMutex.Lock()
+	defer d.stateMutex.Unlock()
+
+	return d.state
+}
+
+// setState moves the driver to state s and notifies the subscribers on the UI
+// goroutine. It returns the previous state.
+func (d *Driver) setState(s State) (prev State) {
+	d.stateMutex.Lock()
+	prev = d.state
+	d.state = s
+	d.stateMutex.Unlock()
+
+	if prev != s {
+		d.stateEvents <- StateEvent{
+			From: prev,
+			To:   s,
+		}
+	}
+	return
+}
+
+// forwardStateEvents delivers the state events to the subscribers, in order,
+// on the UI goroutine.
+func (d *Driver) forwardStateEvents() {
+	for e := range d.stateEvents {
+		e := e
+		app.UIChan <- func() {
+			d.emit(e)
+		}
+	}
+}
+
+// termination tracks a termination request until it is confirmed or
+// cancelled.
+type termination struct {
+	done      chan struct{}
+	cancelled bool
+}
+
+func (d *Driver) pendingTermination() *termination {
+	d.stateMutex.Lock()
+	defer d.stateMutex.Unlock()
+
+	if d.termination == nil {
+		d.termination = &termination{
+			done: make(chan struct{}),
+		}
+	}
+	return d.termination
+}
+
+func (d *Driver) endTermination(cancelled bool) {
+	d.stateMutex.Lock()
+	t := d.termination
+	d.termination = nil
+	d.stateMutex.Unlock()
+
+	if t == nil {
+		return
+	}
+	t.cancelled = cancelled
+	close(t.done)
+}
+
+// Quit asks the app to terminate. The termination goes through the
+// app.OnTerminate function and the subscribers vetoes.
+// It blocks until the termination is confirmed or cancelled, and reports
+// whether it has been cancelled. On macOS, the process exits when the
+// termination is confirmed.
+// Quit must not be called from the UI goroutine since vetoes are collected
+// there: it would block until ctx is done. Event handlers use QuitAsync.
+func (d *Driver) Quit(ctx context.Context) (cancelled bool, err error) {
+	if err = d.checkRunning(); err != nil {
+		return
+	}
+
+	t := d.pendingTermination()
+	d.terminate()
+
+	select {
+	case <-t.done:
+		return t.cancelled, nil
+
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// QuitAsync asks the app to terminate without waiting for the termination to
+// be confirmed or cancelled. Unlike Quit, it can be called from the UI
+// goroutine. The outcome is reported to the subscribers: a vetoed
+// *TerminateEvent when the termination is cancelled, a FinalizeEvent and a
+// StateEvent to StateTerminated when it is confirmed.
+func (d *Driver) QuitAsync() error {
+	if err := d.checkRunning(); err != nil {
+		return err
+	}
+
+	d.terminate()
+	return nil
+}
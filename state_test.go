@@ -0,0 +1,148 @@
+package mac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/murlokswarm/app"
+)
+
+func TestStateString(t *testing.T) {
+	states := []State{
+		StateIdle,
+		StateLaunching,
+		StateRunning,
+		StateTerminating,
+		StateTerminated,
+		State(42),
+	}
+
+	for _, s := range states {
+		t.Log(s)
+	}
+}
+
+func TestDriverState(t *testing.T) {
+	defer driver.setState(StateIdle)
+
+	events := make(chan StateEvent, 64)
+	unsubscribe := driver.Subscribe(func(e Event) {
+		if e, ok := e.(StateEvent); ok && e.To != StateIdle {
+			select {
+			case events <- e:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	driver.setState(StateLaunching)
+	handleLaunch()
+
+	if s := driver.State(); s != StateRunning {
+		t.Error("state should be running:", s)
+	}
+
+	if e := <-events; e.To != StateLaunching {
+		t.Error("unexpected transition:", e)
+	}
+	if e := <-events; e.From != StateLaunching || e.To != StateRunning {
+		t.Error("unexpected transition:", e)
+	}
+}
+
+func TestDriverQuit(t *testing.T) {
+	defer driver.setState(StateIdle)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := driver.Quit(ctx); err == nil {
+		t.Error("quitting an idle driver should return an error")
+	}
+
+	driver.setState(StateRunning)
+	app.OnTerminate = nil
+
+	unsubscribe := driver.Subscribe(func(e Event) {
+		if e, ok := e.(*TerminateEvent); ok {
+			e.Veto()
+		}
+	})
+
+	cancelled, err := driver.Quit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cancelled {
+		t.Error("termination should be cancelled")
+	}
+	if s := driver.State(); s != StateRunning {
+		t.Error("state should be running:", s)
+	}
+
+	unsubscribe()
+
+	if cancelled, err = driver.Quit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if cancelled {
+		t.Error("termination should not be cancelled")
+	}
+	if s := driver.State(); s != StateTerminated {
+		t.Error("state should be terminated:", s)
+	}
+}
+
+func TestDriverQuitAsync(t *testing.T) {
+	defer driver.setState(StateIdle)
+
+	if err := driver.QuitAsync(); err == nil {
+		t.Error("quitting an idle driver should return an error")
+	}
+
+	driver.setState(StateRunning)
+	app.OnTerminate = nil
+
+	vetoed := make(chan bool, 1)
+	unsubscribe := driver.Subscribe(func(e Event) {
+		if e, ok := e.(*TerminateEvent); ok {
+			e.Veto()
+			vetoed <- e.Vetoed()
+		}
+	})
+	defer unsubscribe()
+
+	// Quitting from an event handler must not wait for the vetoes that are
+	// collected on the same goroutine.
+	errc := make(chan error, 1)
+	app.UIChan <- func() {
+		errc <- driver.QuitAsync()
+	}
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("QuitAsync blocked the UI goroutine")
+	}
+
+	select {
+	case v := <-vetoed:
+		if !v {
+			t.Error("termination should be vetoed")
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("no terminate event")
+	}
+
+	fakeNative().flush()
+	if s := driver.State(); s != StateRunning {
+		t.Error("state should be running:", s)
+	}
+}
@@ -1,10 +1,5 @@
 package mac
 
-/*
-#include "storage.h"
-#include "sandbox.h"
-*/
-import "C"
 import (
 	"os"
 	"path/filepath"
@@ -16,8 +11,7 @@ import (
 
 func resources() string {
 	if isAppPackaged() {
-		cresources := C.Storage_Resources()
-		resourcesName := C.GoString(cresources)
+		resourcesName := native.ResourcesDir()
 		createDirIfNotExists(resourcesName)
 		return resourcesName
 	}
@@ -44,7 +38,7 @@ func isAppPackaged() (packaged bool) {
 }
 
 func storage() string {
-	if C.Sandbox_IsSandboxed() != 0 {
+	if native.IsSandboxed() {
 		defaultName := getHomeDirname()
 		createDirIfNotExists(defaultName)
 		return defaultName
@@ -56,16 +50,12 @@ func storage() string {
 }
 
 func getHomeDirname() string {
-	chomeName := C.Storage_Home()
-	return C.GoString(chomeName)
+	return native.HomeDir()
 }
 
 func getSupportDirname() string {
-	csupportName := C.Storage_Support()
-	supportName := C.GoString(csupportName)
-
-	cbundleID := C.Storage_BundleID()
-	bundleID := C.GoString(cbundleID)
+	supportName := native.SupportDir()
+	bundleID := native.BundleID()
 	if len(bundleID) == 0 {
 		wd, err := os.Getwd()
 		if err != nil {
@@ -7,5 +7,6 @@ const char *Storage_Resources();
 const char *Storage_Home();
 const char *Storage_Support();
 const char *Storage_BundleID();
+const char *Storage_BundleName();
 
 #endif /* storage_h */
\ No newline at end of file
@@ -1,3 +1,6 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "storage.h"
 
 const char *Storage_Resources() {
@@ -17,4 +20,13 @@ const char *Storage_Support() {
 const char *Storage_BundleID() {
   NSBundle *mainBundle = [NSBundle mainBundle];
   return mainBundle.bundleIdentifier.UTF8String;
+}
+
+const char *Storage_BundleName() {
+  NSBundle *mainBundle = [NSBundle mainBundle];
+  NSString *name = [mainBundle objectForInfoDictionaryKey:@"CFBundleDisplayName"];
+  if (name.length == 0) {
+    name = [mainBundle objectForInfoDictionaryKey:@"CFBundleName"];
+  }
+  return name.UTF8String;
 }
\ No newline at end of file
@@ -0,0 +1,34 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#include "storage.h"
+#include "sandbox.h"
+*/
+import "C"
+
+func (b cocoaBackend) ResourcesDir() string {
+	return C.GoString(C.Storage_Resources())
+}
+
+func (b cocoaBackend) HomeDir() string {
+	return C.GoString(C.Storage_Home())
+}
+
+func (b cocoaBackend) SupportDir() string {
+	return C.GoString(C.Storage_Support())
+}
+
+func (b cocoaBackend) BundleID() string {
+	return C.GoString(C.Storage_BundleID())
+}
+
+func (b cocoaBackend) BundleName() string {
+	return C.GoString(C.Storage_BundleName())
+}
+
+func (b cocoaBackend) IsSandboxed() bool {
+	return C.Sandbox_IsSandboxed() != 0
+}
@@ -1,10 +1,17 @@
+//go:build !headless
+// +build !headless
+
 package mac
 
 /*
 #import <Foundation/Foundation.h>
 */
 import "C"
-import "unsafe"
+import (
+	"unsafe"
+
+	"github.com/satori/go.uuid"
+)
 
 func boolToBOOL(b bool) C.BOOL {
 	if b {
@@ -17,6 +24,10 @@ func cString(str string) *C.char {
 	return C.CString(str)
 }
 
+func goUUID(cid *C.char) uuid.UUID {
+	return uuid.FromStringOrNil(C.GoString(cid))
+}
+
 func free(p unsafe.Pointer) {
 	C.free(p)
 }
@@ -1,20 +1,17 @@
 package mac
 
-/*
-#include "window.h"
-*/
-import "C"
 import (
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/url"
-	"path/filepath"
+	"os"
 	"strconv"
+	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/murlokswarm/app"
-	"github.com/murlokswarm/cli"
 	"github.com/murlokswarm/log"
 	"github.com/murlokswarm/markup"
 	"github.com/pkg/errors"
@@ -22,35 +19,126 @@ import (
 )
 
 var (
-	winPtrChan    = make(chan unsafe.Pointer)
-	winloadedChan = make(chan bool)
+	pendingWindowsMutex sync.Mutex
+	pendingWindows      = make(map[uuid.UUID]*pendingWindow)
 )
 
+// pendingWindow tracks a window whose native creation is in progress.
+type pendingWindow struct {
+	ptr    chan unsafe.Pointer
+	loaded chan struct{}
+}
+
+func addPendingWindow(id uuid.UUID) *pendingWindow {
+	p := &pendingWindow{
+		ptr:    make(chan unsafe.Pointer, 1),
+		loaded: make(chan struct{}, 1),
+	}
+
+	pendingWindowsMutex.Lock()
+	pendingWindows[id] = p
+	pendingWindowsMutex.Unlock()
+	return p
+}
+
+func getPendingWindow(id uuid.UUID) (p *pendingWindow, ok bool) {
+	pendingWindowsMutex.Lock()
+	p, ok = pendingWindows[id]
+	pendingWindowsMutex.Unlock()
+	return
+}
+
+func removePendingWindow(id uuid.UUID) {
+	pendingWindowsMutex.Lock()
+	delete(pendingWindows, id)
+	pendingWindowsMutex.Unlock()
+}
+
+// Window describes a window with the options that are specific to the
+// driver. It can be passed to NewElement in place of an app.Window.
+type Window struct {
+	app.Window
+
+	// FrameKey is the key under which the position, size, full screen and
+	// minimized state of the window are saved in the storage directory.
+	// A window created with the key of a saved frame is restored with it.
+	// Frames are not saved when FrameKey is empty.
+	FrameKey string
+
+	// OnConfirm is called on the UI goroutine when the page calls confirm().
+	// Its result is returned to the page. A native alert asks the user when
+	// OnConfirm is nil.
+	OnConfirm func(message string) bool
+
+	// OnPrompt is called on the UI goroutine when the page calls prompt().
+	// text is returned to the page, or null when ok is false. A native alert
+	// asks the user when OnPrompt is nil.
+	OnPrompt func(message, defaultText string) (text string, ok bool)
+
+	// OnNavigate is called on the UI goroutine when the webview requests a
+	// navigation. It decides what to do with the navigation. The driver
+	// DefaultNavigationPolicy is used when OnNavigate is nil.
+	OnNavigate func(n Navigation) NavigationPolicy
+
+	// HistorySize is the maximum number of entries kept in the navigation
+	// history of the window. Going back or forward mounts a new component for
+	// the URL of the entry and restores the scroll position of the page. It
+	// defaults to 100.
+	HistorySize int
+
+	// OnHistoryChange is called on the UI goroutine when the navigation
+	// history of the window changes.
+	OnHistoryChange func()
+
+	// Head contains HTML elements added to the head of the window document,
+	// such as meta tags, preload links or bootstrapping data in a
+	// <script type="application/json"> element.
+	Head []string
+
+	// CSP is the Content-Security-Policy of the window document.
+	CSP string
+
+	// StrictCSP restricts the script elements of the window document to the
+	// bundled resources. A policy is generated when CSP is empty, and the
+	// mounted markup is rejected when it has event handlers that are not bound
+	// to a component.
+	// The generated policy does not restrict event handler attributes
+	// (script-src-attr 'unsafe-inline') since the markup is rendered with
+	// them: an on* attribute added to the DOM by a script still runs.
+	// The generated policy requires macOS 12.3 or later: creating the window
+	// on an older version returns an *UnsupportedOSError.
+	StrictCSP bool
+}
+
 type window struct {
 	id        uuid.UUID
 	ptr       unsafe.Pointer
 	component app.Componer
-	config    app.Window
-}
+	config    Window
 
-func newWindow(w app.Window) *window {
-	id := uuid.NewV1()
+	renderMutex sync.Mutex
+	renderBatch renderBatch
 
-	cssDir := filepath.Join(app.Resources(), "css")
-	css := app.GetFilenamesFromDir(cssDir, ".css")
+	frameMutex sync.Mutex
+	frame      WindowFrame
+	frameSave  *time.Timer
+	visible    bool
 
-	jsDir := filepath.Join(app.Resources(), "js")
-	js := app.GetFilenamesFromDir(jsDir, ".js")
+	historyMutex sync.Mutex
+	history      []*historyEntry
+	historyIndex int
 
-	htmlCtx := app.HTMLContext{
-		ID:       id,
-		Title:    w.Title,
-		Lang:     w.Lang,
-		MurlokJS: app.MurlokJS(),
-		JS:       js,
-		CSS:      css,
+	reloadMutex sync.Mutex
+	reloading   bool
+}
+
+func newWindow(w Window) (*window, error) {
+	if err := checkStrictCSP(w); err != nil {
+		return nil, err
 	}
 
+	id := uuid.NewV1()
+
 	if w.MaxWidth <= 0 {
 		w.MaxWidth = 10000
 	}
@@ -59,46 +147,98 @@ func newWindow(w app.Window) *window {
 		w.MaxHeight = 10000
 	}
 
-	cwin := C.Window__{
-		ID:              C.CString(id.String()),
-		Title:           C.CString(w.Title),
-		X:               C.CGFloat(w.X),
-		Y:               C.CGFloat(w.Y),
-		Width:           C.CGFloat(w.Width),
-		Height:          C.CGFloat(w.Height),
-		MinWidth:        C.CGFloat(math.Max(0, w.MinWidth)),
-		MinHeight:       C.CGFloat(math.Max(0, w.MinHeight)),
-		MaxWidth:        C.CGFloat(math.Min(w.MaxWidth, 10000)),
-		MaxHeight:       C.CGFloat(math.Min(w.MaxHeight, 10000)),
-		BackgroundColor: C.CString(w.BackgroundColor),
-		Vibrancy:        C.NSVisualEffectMaterial(w.Vibrancy),
-		Borderless:      boolToBOOL(w.Borderless),
-		FixedSize:       boolToBOOL(w.FixedSize),
-		CloseHidden:     boolToBOOL(w.CloseHidden),
-		MinimizeHidden:  boolToBOOL(w.MinimizeHidden),
-		TitlebarHidden:  boolToBOOL(w.TitlebarHidden),
-		HTML:            C.CString(htmlCtx.HTML()),
-		ResourcePath:    C.CString(app.Resources()),
-	}
-	defer free(unsafe.Pointer(cwin.ID))
-	defer free(unsafe.Pointer(cwin.Title))
-	defer free(unsafe.Pointer(cwin.BackgroundColor))
-	defer free(unsafe.Pointer(cwin.HTML))
-	defer free(unsafe.Pointer(cwin.ResourcePath))
-
-	C.Window_New(cwin)
-	ptr := <-winPtrChan
-	<-winloadedChan
+	saved, restore := restoredWindowFrame(w.FrameKey)
+	if restore {
+		w.X = saved.X
+		w.Y = saved.Y
+		w.Width = saved.Width
+		w.Height = saved.Height
+	}
+
+	pending := addPendingWindow(id)
+	defer removePendingWindow(id)
+
+	native.WindowNew(windowSpec{
+		ID:              id.String(),
+		Title:           w.Title,
+		X:               w.X,
+		Y:               w.Y,
+		Width:           w.Width,
+		Height:          w.Height,
+		MinWidth:        math.Max(0, w.MinWidth),
+		MinHeight:       math.Max(0, w.MinHeight),
+		MaxWidth:        math.Min(w.MaxWidth, 10000),
+		MaxHeight:       math.Min(w.MaxHeight, 10000),
+		BackgroundColor: w.BackgroundColor,
+		Vibrancy:        int(w.Vibrancy),
+		Borderless:      w.Borderless,
+		FixedSize:       w.FixedSize,
+		CloseHidden:     w.CloseHidden,
+		MinimizeHidden:  w.MinimizeHidden,
+		TitlebarHidden:  w.TitlebarHidden,
+		HTML:            windowHTML(id, w),
+		BaseURL:         driver.resourcesBaseURL(),
+		ResourceScheme:  driver.ResourceScheme,
+		UserScript:      windowUserScript(w),
+	})
+
+	timeout := time.After(driver.WindowTimeout)
+
+	var ptr unsafe.Pointer
+	select {
+	case ptr = <-pending.ptr:
+	case <-timeout:
+		return nil, errors.Errorf("creating window %v timed out after %v", id, driver.WindowTimeout)
+	}
+
+	select {
+	case <-pending.loaded:
+	case <-timeout:
+		native.WindowClose(ptr)
+		return nil, errors.Errorf("loading window %v timed out after %v", id, driver.WindowTimeout)
+	}
 
 	win := &window{
 		id:     id,
 		ptr:    ptr,
 		config: w,
+		frame: WindowFrame{
+			X:      w.X,
+			Y:      w.Y,
+			Width:  w.Width,
+			Height: w.Height,
+		},
+		visible: true,
 	}
 	app.Elements().Add(win)
+	driver.addWindow(win)
+
+	native.WindowShow(win.ptr)
 
-	C.Window_Show(win.ptr)
-	return win
+	if restore && saved.FullScreen {
+		native.WindowToggleFullScreen(win.ptr)
+	}
+	if restore && saved.Minimized {
+		native.WindowMinimize(win.ptr)
+	}
+	return win, nil
+}
+
+// restoredWindowFrame returns the frame saved under key, clamped to fit in the
+// current screens.
+func restoredWindowFrame(key string) (f WindowFrame, ok bool) {
+	if len(key) == 0 {
+		return
+	}
+
+	f, err := loadWindowFrame(key)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error(errors.Wrapf(err, "restoring frame %v failed", key))
+		}
+		return
+	}
+	return clampWindowFrame(f, native.Screens()), true
 }
 
 func (w *window) ID() uuid.UUID {
@@ -106,138 +246,428 @@ func (w *window) ID() uuid.UUID {
 }
 
 func (w *window) Mount(c app.Componer) {
+	if err := w.MountE(c); err != nil {
+		log.Panic(err)
+	}
+}
+
+// MountE mounts c in the window and returns the markup error instead of
+// panicking.
+func (w *window) MountE(c app.Componer) error {
+	w.saveScroll()
+	if err := w.mount(c); err != nil {
+		return err
+	}
+
+	w.pushHistory(componentURL(c))
+	return nil
+}
+
+// windowHTML returns the document loaded in the webview of the window
+// identified by id.
+func windowHTML(id uuid.UUID, w Window) string {
+	resources := driver.resourcesFS()
+
+	htmlCtx := app.HTMLContext{
+		ID:       id,
+		Title:    w.Title,
+		Lang:     w.Lang,
+		MurlokJS: app.MurlokJS(),
+		JS:       resourceFilenames(resources, "js", ".js"),
+		CSS:      resourceFilenames(resources, "css", ".css"),
+	}
+
+	if w.StrictCSP {
+		// Inline scripts are blocked by the policy while user scripts are not.
+		htmlCtx.MurlokJS = ""
+	}
+	return customizeHead(htmlCtx.HTML(), w)
+}
+
+// windowUserScript returns the javascript injected in the documents loaded in
+// the webview of a window.
+func windowUserScript(w Window) string {
+	script := consoleJS + historyJS
+	if w.StrictCSP {
+		script += app.MurlokJS()
+	}
+	return script
+}
+
+func (w *window) mount(c app.Componer) error {
+	w.discardRender()
+
 	if w.component != nil {
 		markup.Dismount(w.component)
+		w.component = nil
 	}
 
-	w.component = c
-	if _, err := markup.Mount(c, w.ID()); err != nil {
-		log.Panic(err)
+	root, err := markup.Mount(c, w.ID())
+	if err != nil {
+		return err
+	}
+
+	if w.config.StrictCSP {
+		if err = validateMarkup(root); err != nil {
+			markup.Dismount(c)
+			return err
+		}
 	}
+	w.component = c
+	w.display()
+	return nil
+}
 
-	html := markup.Markup(c)
+// display sends the markup of the mounted component to the webview.
+func (w *window) display() {
+	html := markup.Markup(w.component)
 	html = strconv.Quote(html)
 	call := fmt.Sprintf(`Mount("%v", %v)`, w.ID(), html)
-	ccall := C.CString(call)
-	defer free(unsafe.Pointer(ccall))
-
-	C.Window_CallJS(w.ptr, ccall)
+	evalJS(w.id, w.ptr, call, logJSError("mounting component failed"))
 }
 
 func (w *window) Component() app.Componer {
 	return w.component
 }
 
-func (w *window) Render(s markup.Sync) {
-	if s.Scope == markup.FullSync {
-		w.renderFullNode(s.Node)
-		return
+// HTML returns the markup of the mounted component.
+// It reflects the document displayed in the window, which makes it usable
+// with the headless backend.
+func (w *window) HTML() string {
+	if w.component == nil {
+		return ""
 	}
-	w.renderAttributes(s.Node.ID, s.Attributes)
+	return markup.Markup(w.component)
 }
 
-func (w *window) renderFullNode(n *markup.Node) {
-	html := strconv.Quote(n.Markup())
-	call := fmt.Sprintf(`RenderFull("%v", %v)`, n.ID, html)
-	ccall := C.CString(call)
-	defer free(unsafe.Pointer(ccall))
+// EvalJS evaluates script in the window and returns its result encoded in
+// JSON. An exception thrown by script is returned as a *JSError.
+func (w *window) EvalJS(script string) (json.RawMessage, error) {
+	type evalResult struct {
+		result json.RawMessage
+		err    error
+	}
 
-	C.Window_CallJS(w.ptr, ccall)
-}
+	resultChan := make(chan evalResult, 1)
+	callID := evalJS(w.id, w.ptr, script, func(result json.RawMessage, err error) {
+		resultChan <- evalResult{
+			result: result,
+			err:    err,
+		}
+	})
 
-func (w *window) renderAttributes(nodeID uuid.UUID, attrs markup.AttributeMap) {
-	d, err := json.Marshal(attrs)
-	if err != nil {
-		log.Panic(errors.Wrap(err, "renderAttributes"))
+	select {
+	case r := <-resultChan:
+		return r.result, r.err
+
+	case <-time.After(driver.JSTimeout):
+		popJSCall(callID)
+		return nil, errors.Errorf("evaluating javascript in window %v timed out after %v", w.id, driver.JSTimeout)
 	}
+}
 
-	call := fmt.Sprintf(`RenderAttributes("%v", %v)`, nodeID, string(d))
-	ccall := C.CString(call)
-	defer free(unsafe.Pointer(ccall))
+// EvalJSAsync evaluates script in the window and calls callback on the UI
+// goroutine with its result.
+func (w *window) EvalJSAsync(script string, callback func(result json.RawMessage, err error)) {
+	evalJS(w.id, w.ptr, script, func(result json.RawMessage, err error) {
+		if callback == nil {
+			return
+		}
 
-	C.Window_CallJS(w.ptr, ccall)
+		app.UIChan <- func() {
+			callback(result, err)
+		}
+	})
 }
 
 func (w *window) Position() (x float64, y float64) {
-	frame := C.Window_Frame(w.ptr)
-	x = float64(frame.origin.x)
-	y = float64(frame.origin.y)
+	x, y, _, _ = native.WindowFrame(w.ptr)
 	return
 }
 
 func (w *window) Move(x float64, y float64) {
-	C.Window_Move(w.ptr, C.CGFloat(x), C.CGFloat(y))
+	native.WindowMove(w.ptr, x, y)
 }
 
 func (w *window) Size() (width float64, height float64) {
-	frame := C.Window_Frame(w.ptr)
-	width = float64(frame.size.width)
-	height = float64(frame.size.height)
+	_, _, width, height = native.WindowFrame(w.ptr)
 	return
 }
 
 func (w *window) Resize(width float64, height float64) {
-	C.Window_Resize(w.ptr, C.CGFloat(width), C.CGFloat(height))
+	native.WindowResize(w.ptr, width, height)
+}
+
+// SetTitle sets the title of the window.
+func (w *window) SetTitle(title string) {
+	w.config.Title = title
+	if !w.config.TitlebarHidden {
+		native.WindowSetTitle(w.ptr, title)
+	}
+}
+
+// SetBackgroundColor sets the background color of the window. color is an
+// hexadecimal color such as "#21252b". An empty color resets the default
+// background.
+func (w *window) SetBackgroundColor(color string) {
+	w.config.BackgroundColor = color
+	native.WindowSetBackgroundColor(w.ptr, color)
+}
+
+// SetVibrancy sets the vibrancy effect displayed behind the window content.
+func (w *window) SetVibrancy(v app.Vibrancy) {
+	w.config.Vibrancy = v
+	native.WindowSetVibrancy(w.ptr, int(v))
+}
+
+// SetResizable sets whether the window can be resized by the user.
+func (w *window) SetResizable(resizable bool) {
+	w.config.FixedSize = !resizable
+	native.WindowSetResizable(w.ptr, resizable)
+}
+
+// SetMinMaxSize sets the size limits of the window. Non positive maximums
+// remove the limit.
+func (w *window) SetMinMaxSize(minWidth, minHeight, maxWidth, maxHeight float64) {
+	if maxWidth <= 0 {
+		maxWidth = 10000
+	}
+	if maxHeight <= 0 {
+		maxHeight = 10000
+	}
+
+	w.config.MinWidth = math.Max(0, minWidth)
+	w.config.MinHeight = math.Max(0, minHeight)
+	w.config.MaxWidth = math.Min(maxWidth, 10000)
+	w.config.MaxHeight = math.Min(maxHeight, 10000)
+
+	native.WindowSetMinMaxSize(
+		w.ptr,
+		w.config.MinWidth,
+		w.config.MinHeight,
+		w.config.MaxWidth,
+		w.config.MaxHeight,
+	)
+}
+
+// Minimize minimizes the window into the dock.
+func (w *window) Minimize() {
+	native.WindowMinimize(w.ptr)
+}
+
+// Deminimize restores the window from the dock.
+func (w *window) Deminimize() {
+	native.WindowDeminimize(w.ptr)
+}
+
+// ToggleFullScreen enters or exits the full screen mode.
+func (w *window) ToggleFullScreen() {
+	native.WindowToggleFullScreen(w.ptr)
+}
+
+// Zoom toggles the window between its standard and user frames, like the
+// zoom button.
+func (w *window) Zoom() {
+	native.WindowZoom(w.ptr)
+}
+
+// Center moves the window to the center of its screen.
+func (w *window) Center() {
+	native.WindowCenter(w.ptr)
+}
+
+// IsMinimized reports whether the window is minimized.
+func (w *window) IsMinimized() bool {
+	w.frameMutex.Lock()
+	defer w.frameMutex.Unlock()
+
+	return w.frame.Minimized
+}
+
+// IsFullScreen reports whether the window is in full screen mode.
+func (w *window) IsFullScreen() bool {
+	w.frameMutex.Lock()
+	defer w.frameMutex.Unlock()
+
+	return w.frame.FullScreen
+}
+
+// IsVisible reports whether the window is displayed on screen.
+func (w *window) IsVisible() bool {
+	w.frameMutex.Lock()
+	defer w.frameMutex.Unlock()
+
+	return w.visible && !w.frame.Minimized
+}
+
+// Focus brings the window to front and makes it the key window.
+func (w *window) Focus() {
+	native.WindowFocus(w.ptr)
 }
 
 func (w *window) Close() {
-	C.Window_Close(w.ptr)
+	native.WindowClose(w.ptr)
 }
 
-//export onWindowCreated
-func onWindowCreated(ptr unsafe.Pointer) {
-	winPtrChan <- ptr
+// updateFrame applies update to the frame of the window and saves it once it
+// has not changed for frameSaveDelay.
+func (w *window) updateFrame(update func(f *WindowFrame)) {
+	w.frameMutex.Lock()
+	defer w.frameMutex.Unlock()
+
+	update(&w.frame)
+
+	if len(w.config.FrameKey) == 0 {
+		return
+	}
+	if w.frameSave == nil {
+		w.frameSave = time.AfterFunc(frameSaveDelay, w.saveFrame)
+		return
+	}
+	w.frameSave.Reset(frameSaveDelay)
 }
 
-//export onWindowWebviewLoaded
-func onWindowWebviewLoaded() {
-	winloadedChan <- true
+// flushFrame saves the frame of the window without waiting for the pending
+// save.
+func (w *window) flushFrame() {
+	w.frameMutex.Lock()
+	if w.frameSave != nil {
+		w.frameSave.Stop()
+	}
+	w.frameMutex.Unlock()
+
+	w.saveFrame()
 }
 
-//export onWindowWebviewNavigate
-func onWindowWebviewNavigate(cid *C.char, curl *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-	ctx, ok := app.Elements().Get(id)
+// saveFrame saves the frame of the window on the UI goroutine when the window
+// has a frame key.
+func (w *window) saveFrame() {
+	key := w.config.FrameKey
+	if len(key) == 0 {
+		return
+	}
+
+	w.frameMutex.Lock()
+	f := w.frame
+	w.frameMutex.Unlock()
+
+	app.UIChan <- func() {
+		if err := saveWindowFrame(key, f); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func handleWindowCreated(id uuid.UUID, ptr unsafe.Pointer) {
+	pending, ok := getPendingWindow(id)
 	if !ok {
+		log.Errorf("window %v has been created after its creation was abandoned", id)
+		native.WindowClose(ptr)
 		return
 	}
+	pending.ptr <- ptr
+}
+
+func handleWindowWebviewLoaded(id uuid.UUID) {
+	pending, ok := getPendingWindow(id)
+	if !ok {
+		handleWindowReloaded(id)
+		return
+	}
+
+	select {
+	case pending.loaded <- struct{}{}:
+	default:
+	}
+}
+
+// handleWindowWebviewNavigate applies the navigation policy of the window and
+// reports whether the webview should load the URL.
+func handleWindowWebviewNavigate(id uuid.UUID, rawurl string, navType NavigationType) bool {
+	ctx, ok := app.Elements().Get(id)
+	if !ok {
+		return false
+	}
 	win := ctx.(*window)
 
-	urlString := C.GoString(curl)
-	URL, err := url.Parse(urlString)
+	if win.isReloading() && rawurl == driver.resourcesBaseURL() {
+		return true
+	}
+
+	URL, err := url.Parse(rawurl)
 	if err != nil {
 		log.Error(errors.Wrap(err, "onWindowWebviewNavigate failed"))
-		return
+		return false
 	}
 
-	if URL.Scheme != "component" {
-		cli.Exec("open", URL.String())
-		return
+	nav := Navigation{
+		URL:  URL,
+		Type: navType,
+	}
+
+	policy := driver.DefaultNavigationPolicy(nav)
+	if win.config.OnNavigate != nil {
+		policyChan := make(chan NavigationPolicy)
+
+		app.UIChan <- func() {
+			policyChan <- win.config.OnNavigate(nav)
+		}
+		policy = <-policyChan
 	}
 
+	switch policy {
+	case NavigationAllow:
+		return true
+
+	case NavigationOpenExternal:
+		native.OpenURL(URL.String())
+
+	case NavigationRoute:
+		win.route(URL)
+
+	default:
+		log.Warnf("navigation to %v in window %v has been denied", URL, id)
+	}
+	return false
+}
+
+// route mounts the component targeted by URL.
+func (w *window) route(URL *url.URL) {
 	app.UIChan <- func() {
-		c, err := markup.New(URL.Host)
+		c, err := driver.Router.Resolve(URL)
 		if err != nil {
-			log.Error(errors.Wrap(err, "onWindowWebviewNavigate failed"))
+			log.Error(errors.Wrapf(err, "routing %v failed", URL))
 			return
 		}
 
-		win.Mount(c)
-		if hrefer, ok := c.(app.Hrefer); ok {
-			hrefer.OnHref(URL)
+		if err = w.mountRouted(c, URL); err != nil {
+			log.Error(err)
 		}
 	}
 }
 
-//export onWindowMinimize
-func onWindowMinimize(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
+// mountRouted mounts c, the component routed for URL. It must be called on the
+// UI goroutine.
+func (w *window) mountRouted(c app.Componer, URL *url.URL) error {
+	w.saveScroll()
+	if err := w.mount(c); err != nil {
+		return errors.Wrapf(err, "routing %v failed", URL)
+	}
+	w.pushHistory(URL)
+
+	if hrefer, ok := c.(app.Hrefer); ok {
+		hrefer.OnHref(URL)
+	}
+	return nil
+}
 
+func handleWindowMinimize(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	win.updateFrame(func(f *WindowFrame) { f.Minimized = true })
 
 	app.UIChan <- func() {
 		if win.config.OnMinimize != nil {
@@ -246,15 +676,13 @@ func onWindowMinimize(cid *C.char) {
 	}
 }
 
-//export onWindowDeminimize
-func onWindowDeminimize(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowDeminimize(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	win.updateFrame(func(f *WindowFrame) { f.Minimized = false })
 
 	app.UIChan <- func() {
 		if win.config.OnDeminimize != nil {
@@ -263,15 +691,13 @@ func onWindowDeminimize(cid *C.char) {
 	}
 }
 
-//export onWindowFullScreen
-func onWindowFullScreen(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowFullScreen(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	win.updateFrame(func(f *WindowFrame) { f.FullScreen = true })
 
 	app.UIChan <- func() {
 		if win.config.OnFullScreen != nil {
@@ -280,15 +706,13 @@ func onWindowFullScreen(cid *C.char) {
 	}
 }
 
-//export onWindowExitFullScreen
-func onWindowExitFullScreen(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowExitFullScreen(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	win.updateFrame(func(f *WindowFrame) { f.FullScreen = false })
 
 	app.UIChan <- func() {
 		if win.config.OnExitFullScreen != nil {
@@ -297,18 +721,17 @@ func onWindowExitFullScreen(cid *C.char) {
 	}
 }
 
-//export onWindowMove
-func onWindowMove(cid *C.char, cx C.CGFloat, cy C.CGFloat) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowMove(id uuid.UUID, x float64, y float64) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
-
-	x := float64(cx)
-	y := float64(cy)
+	win.updateFrame(func(f *WindowFrame) {
+		if !f.FullScreen {
+			f.X, f.Y = x, y
+		}
+	})
 
 	app.UIChan <- func() {
 		if win.config.OnMove != nil {
@@ -317,35 +740,32 @@ func onWindowMove(cid *C.char, cx C.CGFloat, cy C.CGFloat) {
 	}
 }
 
-//export onWindowResize
-func onWindowResize(cid *C.char, width C.CGFloat, height C.CGFloat) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowResize(id uuid.UUID, width float64, height float64) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
-
-	w := float64(width)
-	h := float64(height)
+	win.updateFrame(func(f *WindowFrame) {
+		if !f.FullScreen {
+			f.Width, f.Height = width, height
+		}
+	})
 
 	app.UIChan <- func() {
 		if win.config.OnResize != nil {
-			win.config.OnResize(w, h)
+			win.config.OnResize(width, height)
 		}
 	}
 }
 
-//export onWindowFocus
-func onWindowFocus(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowFocus(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	driver.setFocusedWindow(win, true)
 
 	app.UIChan <- func() {
 		if win.config.OnFocus != nil {
@@ -354,15 +774,13 @@ func onWindowFocus(cid *C.char) {
 	}
 }
 
-//export onWindowBlur
-func onWindowBlur(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowBlur(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
+	driver.setFocusedWindow(win, false)
 
 	app.UIChan <- func() {
 		if win.config.OnBlur != nil {
@@ -371,10 +789,7 @@ func onWindowBlur(cid *C.char) {
 	}
 }
 
-//export onWindowClose
-func onWindowClose(cid *C.char) bool {
-	id := uuid.FromStringOrNil(C.GoString(cid))
-
+func handleWindowClose(id uuid.UUID) bool {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return true
@@ -393,16 +808,69 @@ func onWindowClose(cid *C.char) bool {
 	return <-closeChan
 }
 
-//export onWindowCloseFinal
-func onWindowCloseFinal(cid *C.char) {
-	id := uuid.FromStringOrNil(C.GoString(cid))
+func handleWindowConfirm(id uuid.UUID, message string) bool {
+	ctx, ok := app.Elements().Get(id)
+	if !ok {
+		return false
+	}
+	win := ctx.(*window)
+
+	if win.config.OnConfirm == nil {
+		return native.WindowConfirm(win.ptr, message)
+	}
 
+	confirmChan := make(chan bool)
+
+	app.UIChan <- func() {
+		confirmChan <- win.config.OnConfirm(message)
+	}
+	return <-confirmChan
+}
+
+func handleWindowPrompt(id uuid.UUID, message, defaultText string) (text string, ok bool) {
+	ctx, ok := app.Elements().Get(id)
+	if !ok {
+		return "", false
+	}
+	win := ctx.(*window)
+
+	if win.config.OnPrompt == nil {
+		return native.WindowPrompt(win.ptr, message, defaultText)
+	}
+
+	type promptResult struct {
+		text string
+		ok   bool
+	}
+	promptChan := make(chan promptResult)
+
+	app.UIChan <- func() {
+		text, ok := win.config.OnPrompt(message, defaultText)
+		promptChan <- promptResult{
+			text: text,
+			ok:   ok,
+		}
+	}
+
+	res := <-promptChan
+	return res.text, res.ok
+}
+
+func handleWindowCloseFinal(id uuid.UUID) {
 	ctx, ok := app.Elements().Get(id)
 	if !ok {
 		return
 	}
 	win := ctx.(*window)
 
+	driver.removeWindow(win)
+	win.frameMutex.Lock()
+	win.visible = false
+	win.frameMutex.Unlock()
+	win.flushFrame()
+	win.discardRender()
+	cancelJSCalls(id, errors.Errorf("window %v has been closed", id))
+
 	app.UIChan <- func() {
 		markup.Dismount(win.component)
 		app.Elements().Remove(win)
@@ -23,12 +23,14 @@ typedef struct Window__ {
   BOOL MinimizeHidden;
   BOOL TitlebarHidden;
   const char *HTML;
-  const char *ResourcePath;
+  const char *BaseURL;
+  const char *ResourceScheme;
+  const char *UserScript;
 } Window__;
 
 @interface WindowController
     : NSWindowController <NSWindowDelegate, WKNavigationDelegate, WKUIDelegate,
-                          WKScriptMessageHandler>
+                          WKScriptMessageHandler, WKURLSchemeHandler>
 @property NSString *ID;
 @property(weak) WKWebView *webview;
 
@@ -41,14 +43,40 @@ typedef struct Window__ {
 void Window_New(Window__ w);
 void Window_new(Window__ w);
 WKWebView *Window_NewWebview(WindowController *controller, NSString *HTML,
-                             NSString *resourcePath);
+                             NSString *baseURL, NSString *resourceScheme,
+                             NSString *userScript);
+void Window_RespondResource(void *task, int status, const char *mimeType,
+                            const void *data, int length);
 void Window_SetWebview(NSWindow *win, WKWebView *webview);
 void Window_SetTitleBar(NSWindow *win, TitleBar *titleBar);
 void Window_Show(const void *ptr);
-void Window_CallJS(const void *ptr, const char *js);
+void Window_Focus(const void *ptr);
+void Window_EvalJS(const void *ptr, const char *callID, const char *js);
+void Window_evalJS(NSWindow *win, NSString *callID, NSString *javaScript);
+void Window_LoadHTML(const void *ptr, const char *HTML, const char *baseURL);
+void Window_loadHTML(NSWindow *win, NSString *HTML, NSString *baseURL);
+void Window_SetTitle(const void *ptr, const char *title);
+void Window_SetBackgroundColor(const void *ptr, const char *color);
+void Window_setBackgroundColor(NSWindow *win, NSString *color);
+void Window_SetVibrancy(const void *ptr, NSVisualEffectMaterial vibrancy);
+void Window_setVibrancy(NSWindow *win, NSVisualEffectMaterial vibrancy);
+void Window_SetResizable(const void *ptr, BOOL resizable);
+void Window_setResizable(NSWindow *win, BOOL resizable);
+void Window_SetMinMaxSize(const void *ptr, CGFloat minWidth, CGFloat minHeight,
+                          CGFloat maxWidth, CGFloat maxHeight);
+BOOL Window_Confirm(const void *ptr, const char *message);
+char *Window_Prompt(const void *ptr, const char *message,
+                    const char *defaultText);
 NSRect Window_Frame(const void *ptr);
 void Window_Move(const void *ptr, CGFloat x, CGFloat y);
 void Window_Resize(const void *ptr, CGFloat width, CGFloat height);
+void Window_Minimize(const void *ptr);
+void Window_Deminimize(const void *ptr);
+void Window_Zoom(const void *ptr);
+void Window_Center(const void *ptr);
+void Window_ToggleFullScreen(const void *ptr);
+int Window_ScreenCount();
+NSRect Window_ScreenFrame(int i);
 void Window_Close(const void *ptr);
 
 #endif /* window_h */
@@ -1,8 +1,26 @@
+//go:build darwin && !headless
+// +build darwin,!headless
+
 #include "window.h"
 #include "_cgo_export.h"
 #include "color.h"
 
-void Window_New(Window__ w) { defer(Window_new(w);); }
+void Window_New(Window__ w) {
+  // Strings are released by Go when Window_New returns while the window is
+  // created later on the main queue. They are copied to stay valid.
+  w.ID = strdup(w.ID);
+  w.Title = strdup(w.Title);
+  w.BackgroundColor = strdup(w.BackgroundColor);
+  w.HTML = strdup(w.HTML);
+  w.BaseURL = strdup(w.BaseURL);
+  w.ResourceScheme = strdup(w.ResourceScheme);
+  w.UserScript = strdup(w.UserScript);
+
+  defer(Window_new(w); free((void *)w.ID); free((void *)w.Title);
+        free((void *)w.BackgroundColor); free((void *)w.HTML);
+        free((void *)w.BaseURL); free((void *)w.ResourceScheme);
+        free((void *)w.UserScript););
+}
 
 void Window_new(Window__ w) {
   NSRect contentRect = NSMakeRect(w.X, w.Y, w.Width, w.Height);
@@ -64,13 +82,13 @@ void Window_new(Window__ w) {
   controller.window = win;
   win.delegate = controller;
   win.windowController = controller;
-  win.windowController.windowFrameAutosaveName =
-      [NSString stringWithUTF8String:w.Title];
 
   // WebView.
   WKWebView *webview =
       Window_NewWebview(controller, [NSString stringWithUTF8String:w.HTML],
-                        [NSString stringWithUTF8String:w.ResourcePath]);
+                        [NSString stringWithUTF8String:w.BaseURL],
+                        [NSString stringWithUTF8String:w.ResourceScheme],
+                        [NSString stringWithUTF8String:w.UserScript]);
   Window_SetWebview(win, webview);
   controller.webview = webview;
 
@@ -83,18 +101,33 @@ void Window_new(Window__ w) {
     win.title = [NSString stringWithUTF8String:w.Title];
   }
 
-  onWindowCreated((void *)CFBridgingRetain(win));
+  onWindowCreated((char *)id.UTF8String, (void *)CFBridgingRetain(win));
 }
 
 WKWebView *Window_NewWebview(WindowController *controller, NSString *HTML,
-                             NSString *resourcePath) {
+                             NSString *baseURL, NSString *resourceScheme,
+                             NSString *userScript) {
   WKUserContentController *userContentController =
       [[WKUserContentController alloc] init];
   [userContentController addScriptMessageHandler:controller name:@"Call"];
+  [userContentController addScriptMessageHandler:controller name:@"Console"];
+  [userContentController addScriptMessageHandler:controller name:@"History"];
+
+  // The user script runs before the page scripts.
+  if (userScript.length != 0) {
+    WKUserScript *script = [[WKUserScript alloc]
+          initWithSource:userScript
+           injectionTime:WKUserScriptInjectionTimeAtDocumentStart
+        forMainFrameOnly:YES];
+    [userContentController addUserScript:script];
+  }
 
   WKWebViewConfiguration *conf = [[WKWebViewConfiguration alloc] init];
   conf.userContentController = userContentController;
 
+  // Resources are served by Go.
+  [conf setURLSchemeHandler:controller forURLScheme:resourceScheme];
+
   WKWebView *webview = [[WKWebView alloc] initWithFrame:NSMakeRect(0, 0, 0, 0)
                                           configuration:conf];
   [webview setValue:@(NO) forKey:@"drawsBackground"];
@@ -102,11 +135,29 @@ WKWebView *Window_NewWebview(WindowController *controller, NSString *HTML,
   webview.UIDelegate = controller;
 
   // Page loading.
-  NSURL *baseURL = [NSURL fileURLWithPath:resourcePath];
-  [webview loadHTMLString:HTML baseURL:baseURL];
+  [webview loadHTMLString:HTML baseURL:[NSURL URLWithString:baseURL]];
   return webview;
 }
 
+void Window_RespondResource(void *task, int status, const char *mimeType,
+                            const void *data, int length) {
+  id<WKURLSchemeTask> urlSchemeTask = (__bridge id<WKURLSchemeTask>)task;
+  NSDictionary *headers = @{
+    @"Content-Type" : [NSString stringWithUTF8String:mimeType],
+    @"Content-Length" : [NSString stringWithFormat:@"%d", length],
+  };
+
+  NSHTTPURLResponse *response =
+      [[NSHTTPURLResponse alloc] initWithURL:urlSchemeTask.request.URL
+                                  statusCode:status
+                                 HTTPVersion:@"HTTP/1.1"
+                                headerFields:headers];
+
+  [urlSchemeTask didReceiveResponse:response];
+  [urlSchemeTask didReceiveData:[NSData dataWithBytes:data length:length]];
+  [urlSchemeTask didFinish];
+}
+
 void Window_SetWebview(NSWindow *win, WKWebView *webview) {
   webview.translatesAutoresizingMaskIntoConstraints = NO;
   [win.contentView addSubview:webview];
@@ -156,12 +207,206 @@ void Window_Show(const void *ptr) {
   defer([win makeKeyAndOrderFront:nil];);
 }
 
-void Window_CallJS(const void *ptr, const char *js) {
+void Window_Focus(const void *ptr) {
   NSWindow *win = (__bridge NSWindow *)ptr;
-  WindowController *controller = (WindowController *)win.windowController;
+  defer([NSApp activateIgnoringOtherApps:YES];
+        [win makeKeyAndOrderFront:nil];);
+}
 
+void Window_EvalJS(const void *ptr, const char *callID, const char *js) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  NSString *ID = [NSString stringWithUTF8String:callID];
   NSString *javaScript = [NSString stringWithUTF8String:js];
-  [controller.webview evaluateJavaScript:javaScript completionHandler:nil];
+
+  defer(Window_evalJS(win, ID, javaScript););
+}
+
+void Window_evalJS(NSWindow *win, NSString *callID, NSString *javaScript) {
+  WindowController *controller = (WindowController *)win.windowController;
+
+  [controller.webview
+      evaluateJavaScript:javaScript
+       completionHandler:^(id result, NSError *error) {
+         NSString *resultJSON = @"[null]";
+         NSString *errorMessage = @"";
+
+         if (error != nil) {
+           NSString *exception =
+               error.userInfo[@"WKJavaScriptExceptionMessage"];
+           errorMessage =
+               exception != nil ? exception : error.localizedDescription;
+         } else if (result != nil) {
+           // The result is wrapped in an array to allow JSON fragments.
+           NSArray *wrapped = @[ result ];
+
+           if ([NSJSONSerialization isValidJSONObject:wrapped]) {
+             NSData *data = [NSJSONSerialization dataWithJSONObject:wrapped
+                                                            options:0
+                                                              error:nil];
+             resultJSON = [[NSString alloc] initWithData:data
+                                                encoding:NSUTF8StringEncoding];
+           } else {
+             errorMessage = @"result can't be encoded in JSON";
+           }
+         }
+
+         onWindowJSResult((char *)callID.UTF8String,
+                          (char *)resultJSON.UTF8String,
+                          (char *)errorMessage.UTF8String);
+       }];
+}
+
+void Window_LoadHTML(const void *ptr, const char *HTML, const char *baseURL) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  NSString *html = [NSString stringWithUTF8String:HTML];
+  NSString *base = [NSString stringWithUTF8String:baseURL];
+
+  defer(Window_loadHTML(win, html, base););
+}
+
+void Window_loadHTML(NSWindow *win, NSString *HTML, NSString *baseURL) {
+  WindowController *controller = (WindowController *)win.windowController;
+  [controller.webview loadHTMLString:HTML baseURL:[NSURL URLWithString:baseURL]];
+}
+
+void Window_SetTitle(const void *ptr, const char *title) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  NSString *t = [NSString stringWithUTF8String:title];
+
+  defer(win.title = t;);
+}
+
+void Window_SetBackgroundColor(const void *ptr, const char *color) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  NSString *c = [NSString stringWithUTF8String:color];
+
+  defer(Window_setBackgroundColor(win, c););
+}
+
+void Window_setBackgroundColor(NSWindow *win, NSString *color) {
+  if (color.length == 0) {
+    win.backgroundColor = [NSColor windowBackgroundColor];
+    return;
+  }
+
+  CIColor *backgroundColor = [CIColor colorWithHexString:color];
+  win.backgroundColor = [NSColor colorWithCIColor:backgroundColor];
+}
+
+void Window_SetVibrancy(const void *ptr, NSVisualEffectMaterial vibrancy) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer(Window_setVibrancy(win, vibrancy););
+}
+
+void Window_setVibrancy(NSWindow *win, NSVisualEffectMaterial vibrancy) {
+  NSVisualEffectView *visualEffectView = nil;
+
+  if ([win.contentView isKindOfClass:[NSVisualEffectView class]]) {
+    visualEffectView = (NSVisualEffectView *)win.contentView;
+  } else {
+    for (NSView *view in win.contentView.subviews) {
+      if ([view isKindOfClass:[NSVisualEffectView class]]) {
+        visualEffectView = (NSVisualEffectView *)view;
+        break;
+      }
+    }
+  }
+
+  if (vibrancy == NSVisualEffectMaterialAppearanceBased) {
+    visualEffectView.state = NSVisualEffectStateInactive;
+    if (visualEffectView != win.contentView) {
+      visualEffectView.hidden = YES;
+    }
+    return;
+  }
+
+  // The content view holds the webview. The visual effect view is added
+  // behind it.
+  if (visualEffectView == nil) {
+    visualEffectView = [[NSVisualEffectView alloc] init];
+    visualEffectView.blendingMode = NSVisualEffectBlendingModeBehindWindow;
+    visualEffectView.translatesAutoresizingMaskIntoConstraints = NO;
+
+    [win.contentView addSubview:visualEffectView
+                     positioned:NSWindowBelow
+                     relativeTo:nil];
+    [win.contentView
+        addConstraints:
+            [NSLayoutConstraint
+                constraintsWithVisualFormat:@"|[visualEffectView]|"
+                                    options:0
+                                    metrics:nil
+                                      views:NSDictionaryOfVariableBindings(
+                                                visualEffectView)]];
+    [win.contentView
+        addConstraints:
+            [NSLayoutConstraint
+                constraintsWithVisualFormat:@"V:|[visualEffectView]|"
+                                    options:0
+                                    metrics:nil
+                                      views:NSDictionaryOfVariableBindings(
+                                                visualEffectView)]];
+  }
+
+  visualEffectView.material = vibrancy;
+  visualEffectView.state = NSVisualEffectStateActive;
+  visualEffectView.hidden = NO;
+}
+
+void Window_SetResizable(const void *ptr, BOOL resizable) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer(Window_setResizable(win, resizable););
+}
+
+void Window_setResizable(NSWindow *win, BOOL resizable) {
+  if (resizable) {
+    win.styleMask = win.styleMask | NSWindowStyleMaskResizable;
+    return;
+  }
+  win.styleMask = win.styleMask & ~NSWindowStyleMaskResizable;
+}
+
+void Window_SetMinMaxSize(const void *ptr, CGFloat minWidth, CGFloat minHeight,
+                          CGFloat maxWidth, CGFloat maxHeight) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  NSSize minSize = NSMakeSize(minWidth, minHeight);
+  NSSize maxSize = NSMakeSize(maxWidth, maxHeight);
+
+  defer(win.minSize = minSize; win.maxSize = maxSize;);
+}
+
+BOOL Window_Confirm(const void *ptr, const char *message) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+
+  NSAlert *alert = [[NSAlert alloc] init];
+  alert.messageText = win.title;
+  alert.informativeText = [NSString stringWithUTF8String:message];
+  [alert addButtonWithTitle:@"OK"];
+  [alert addButtonWithTitle:@"Cancel"];
+
+  return [alert runModal] == NSAlertFirstButtonReturn;
+}
+
+char *Window_Prompt(const void *ptr, const char *message,
+                    const char *defaultText) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+
+  NSTextField *input =
+      [[NSTextField alloc] initWithFrame:NSMakeRect(0, 0, 260, 24)];
+  input.stringValue = [NSString stringWithUTF8String:defaultText];
+
+  NSAlert *alert = [[NSAlert alloc] init];
+  alert.messageText = win.title;
+  alert.informativeText = [NSString stringWithUTF8String:message];
+  alert.accessoryView = input;
+  [alert addButtonWithTitle:@"OK"];
+  [alert addButtonWithTitle:@"Cancel"];
+  [alert.window setInitialFirstResponder:input];
+
+  if ([alert runModal] != NSAlertFirstButtonReturn) {
+    return NULL;
+  }
+  return strdup(input.stringValue.UTF8String);
 }
 
 NSRect Window_Frame(const void *ptr) {
@@ -185,6 +430,35 @@ void Window_Resize(const void *ptr, CGFloat width, CGFloat height) {
   defer([win setFrame:frame display:YES];);
 }
 
+void Window_Minimize(const void *ptr) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer([win miniaturize:nil];);
+}
+
+void Window_Deminimize(const void *ptr) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer([win deminiaturize:nil];);
+}
+
+void Window_Zoom(const void *ptr) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer([win zoom:nil];);
+}
+
+void Window_Center(const void *ptr) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer([win center];);
+}
+
+void Window_ToggleFullScreen(const void *ptr) {
+  NSWindow *win = (__bridge NSWindow *)ptr;
+  defer([win toggleFullScreen:nil];);
+}
+
+int Window_ScreenCount() { return (int)NSScreen.screens.count; }
+
+NSRect Window_ScreenFrame(int i) { return NSScreen.screens[i].visibleFrame; }
+
 void Window_Close(const void *ptr) {
   NSWindow *win = (__bridge NSWindow *)ptr;
   defer([win performClose:nil];);
@@ -198,7 +472,7 @@ void Window_Close(const void *ptr) {
 
 - (void)webView:(WKWebView *)webView
     didFinishNavigation:(WKNavigation *)navigation {
-  onWindowWebviewLoaded();
+  onWindowWebviewLoaded((char *)self.ID.UTF8String);
 }
 
 - (void)userContentController:(WKUserContentController *)userContentController
@@ -206,6 +480,19 @@ void Window_Close(const void *ptr) {
   if ([message.name isEqual:@"Call"]) {
     NSString *msg = (NSString *)message.body;
     onJSCall((char *)msg.UTF8String);
+    return;
+  }
+
+  if ([message.name isEqual:@"Console"]) {
+    NSString *entry = (NSString *)message.body;
+    onJSConsole((char *)self.ID.UTF8String, (char *)entry.UTF8String);
+    return;
+  }
+
+  if ([message.name isEqual:@"History"]) {
+    NSString *direction = (NSString *)message.body;
+    onWindowHistory((char *)self.ID.UTF8String,
+                    (char *)direction.UTF8String);
   }
 }
 
@@ -213,21 +500,31 @@ void Window_Close(const void *ptr) {
     decidePolicyForNavigationAction:(WKNavigationAction *)navigationAction
                     decisionHandler:
                         (void (^)(WKNavigationActionPolicy))decisionHandler {
-  if (navigationAction.navigationType == WKNavigationTypeReload ||
-      navigationAction.navigationType == WKNavigationTypeOther) {
-    if (navigationAction.targetFrame.request != nil) {
-      decisionHandler(WKNavigationActionPolicyCancel);
-      return;
-    }
-
+  // Initial page loading.
+  if (navigationAction.navigationType == WKNavigationTypeOther &&
+      navigationAction.targetFrame.request == nil) {
     decisionHandler(WKNavigationActionPolicyAllow);
     return;
   }
 
   NSURL *url = navigationAction.request.URL;
-  onWindowWebviewNavigate((char *)self.ID.UTF8String,
-                          (char *)url.absoluteString.UTF8String);
-  decisionHandler(WKNavigationActionPolicyCancel);
+  BOOL allow = onWindowWebviewNavigate((char *)self.ID.UTF8String,
+                                       (char *)url.absoluteString.UTF8String,
+                                       (int)navigationAction.navigationType);
+  decisionHandler(allow ? WKNavigationActionPolicyAllow
+                        : WKNavigationActionPolicyCancel);
+}
+
+- (void)webView:(WKWebView *)webView
+    startURLSchemeTask:(id<WKURLSchemeTask>)urlSchemeTask {
+  onWindowResourceRequest(
+      (__bridge void *)urlSchemeTask,
+      (char *)urlSchemeTask.request.URL.absoluteString.UTF8String);
+}
+
+- (void)webView:(WKWebView *)webView
+    stopURLSchemeTask:(id<WKURLSchemeTask>)urlSchemeTask {
+  // Tasks are completed synchronously in startURLSchemeTask.
 }
 
 - (void)webView:(WKWebView *)webView
@@ -238,6 +535,36 @@ void Window_Close(const void *ptr) {
   completionHandler();
 }
 
+- (void)webView:(WKWebView *)webView
+    runJavaScriptConfirmPanelWithMessage:(NSString *)message
+                        initiatedByFrame:(WKFrameInfo *)frame
+                       completionHandler:(void (^)(BOOL))completionHandler {
+  completionHandler(onWindowConfirm((char *)self.ID.UTF8String,
+                                    (char *)message.UTF8String));
+}
+
+- (void)webView:(WKWebView *)webView
+    runJavaScriptTextInputPanelWithPrompt:(NSString *)prompt
+                              defaultText:(NSString *)defaultText
+                         initiatedByFrame:(WKFrameInfo *)frame
+                        completionHandler:
+                            (void (^)(NSString *))completionHandler {
+  if (defaultText == nil) {
+    defaultText = @"";
+  }
+
+  char *text = onWindowPrompt((char *)self.ID.UTF8String,
+                              (char *)prompt.UTF8String,
+                              (char *)defaultText.UTF8String);
+  if (text == NULL) {
+    completionHandler(nil);
+    return;
+  }
+
+  completionHandler([NSString stringWithUTF8String:text]);
+  free(text);
+}
+
 - (void)windowDidMiniaturize:(NSNotification *)notification {
   onWindowMinimize((char *)self.ID.UTF8String);
 }
@@ -0,0 +1,305 @@
+//go:build !headless
+// +build !headless
+
+package mac
+
+/*
+#include "window.h"
+*/
+import "C"
+import (
+	"unsafe"
+
+	"github.com/murlokswarm/cli"
+)
+
+func (b cocoaBackend) WindowNew(w windowSpec) {
+	cwin := C.Window__{
+		ID:              cString(w.ID),
+		Title:           cString(w.Title),
+		X:               C.CGFloat(w.X),
+		Y:               C.CGFloat(w.Y),
+		Width:           C.CGFloat(w.Width),
+		Height:          C.CGFloat(w.Height),
+		MinWidth:        C.CGFloat(w.MinWidth),
+		MinHeight:       C.CGFloat(w.MinHeight),
+		MaxWidth:        C.CGFloat(w.MaxWidth),
+		MaxHeight:       C.CGFloat(w.MaxHeight),
+		BackgroundColor: cString(w.BackgroundColor),
+		Vibrancy:        C.NSVisualEffectMaterial(w.Vibrancy),
+		Borderless:      boolToBOOL(w.Borderless),
+		FixedSize:       boolToBOOL(w.FixedSize),
+		CloseHidden:     boolToBOOL(w.CloseHidden),
+		MinimizeHidden:  boolToBOOL(w.MinimizeHidden),
+		TitlebarHidden:  boolToBOOL(w.TitlebarHidden),
+		HTML:            cString(w.HTML),
+		BaseURL:         cString(w.BaseURL),
+		ResourceScheme:  cString(w.ResourceScheme),
+		UserScript:      cString(w.UserScript),
+	}
+	defer free(unsafe.Pointer(cwin.ID))
+	defer free(unsafe.Pointer(cwin.Title))
+	defer free(unsafe.Pointer(cwin.BackgroundColor))
+	defer free(unsafe.Pointer(cwin.HTML))
+	defer free(unsafe.Pointer(cwin.BaseURL))
+	defer free(unsafe.Pointer(cwin.ResourceScheme))
+	defer free(unsafe.Pointer(cwin.UserScript))
+
+	C.Window_New(cwin)
+}
+
+func (b cocoaBackend) WindowShow(win unsafe.Pointer) {
+	C.Window_Show(win)
+}
+
+func (b cocoaBackend) WindowFocus(win unsafe.Pointer) {
+	C.Window_Focus(win)
+}
+
+func (b cocoaBackend) WindowEvalJS(win unsafe.Pointer, callID string, js string) {
+	ccallID := cString(callID)
+	cjs := cString(js)
+	defer free(unsafe.Pointer(ccallID))
+	defer free(unsafe.Pointer(cjs))
+
+	C.Window_EvalJS(win, ccallID, cjs)
+}
+
+func (b cocoaBackend) WindowLoadHTML(win unsafe.Pointer, html string, baseURL string) {
+	chtml := cString(html)
+	cbaseURL := cString(baseURL)
+	defer free(unsafe.Pointer(chtml))
+	defer free(unsafe.Pointer(cbaseURL))
+
+	C.Window_LoadHTML(win, chtml, cbaseURL)
+}
+
+func (b cocoaBackend) WindowSetTitle(win unsafe.Pointer, title string) {
+	ctitle := cString(title)
+	defer free(unsafe.Pointer(ctitle))
+
+	C.Window_SetTitle(win, ctitle)
+}
+
+func (b cocoaBackend) WindowSetBackgroundColor(win unsafe.Pointer, color string) {
+	ccolor := cString(color)
+	defer free(unsafe.Pointer(ccolor))
+
+	C.Window_SetBackgroundColor(win, ccolor)
+}
+
+func (b cocoaBackend) WindowSetVibrancy(win unsafe.Pointer, vibrancy int) {
+	C.Window_SetVibrancy(win, C.NSVisualEffectMaterial(vibrancy))
+}
+
+func (b cocoaBackend) WindowSetResizable(win unsafe.Pointer, resizable bool) {
+	C.Window_SetResizable(win, boolToBOOL(resizable))
+}
+
+func (b cocoaBackend) WindowSetMinMaxSize(win unsafe.Pointer, minWidth, minHeight, maxWidth, maxHeight float64) {
+	C.Window_SetMinMaxSize(
+		win,
+		C.CGFloat(minWidth),
+		C.CGFloat(minHeight),
+		C.CGFloat(maxWidth),
+		C.CGFloat(maxHeight),
+	)
+}
+
+// WindowConfirm must be called on the main thread.
+func (b cocoaBackend) WindowConfirm(win unsafe.Pointer, message string) bool {
+	cmessage := cString(message)
+	defer free(unsafe.Pointer(cmessage))
+
+	return C.Window_Confirm(win, cmessage) != 0
+}
+
+// WindowPrompt must be called on the main thread.
+func (b cocoaBackend) WindowPrompt(win unsafe.Pointer, message, defaultText string) (text string, ok bool) {
+	cmessage := cString(message)
+	cdefaultText := cString(defaultText)
+	defer free(unsafe.Pointer(cmessage))
+	defer free(unsafe.Pointer(cdefaultText))
+
+	ctext := C.Window_Prompt(win, cmessage, cdefaultText)
+	if ctext == nil {
+		return "", false
+	}
+	defer free(unsafe.Pointer(ctext))
+	return C.GoString(ctext), true
+}
+
+func (b cocoaBackend) OpenURL(rawurl string) {
+	cli.Exec("open", rawurl)
+}
+
+func (b cocoaBackend) WindowFrame(win unsafe.Pointer) (x, y, width, height float64) {
+	frame := C.Window_Frame(win)
+	x = float64(frame.origin.x)
+	y = float64(frame.origin.y)
+	width = float64(frame.size.width)
+	height = float64(frame.size.height)
+	return
+}
+
+func (b cocoaBackend) WindowMove(win unsafe.Pointer, x, y float64) {
+	C.Window_Move(win, C.CGFloat(x), C.CGFloat(y))
+}
+
+func (b cocoaBackend) WindowResize(win unsafe.Pointer, width, height float64) {
+	C.Window_Resize(win, C.CGFloat(width), C.CGFloat(height))
+}
+
+func (b cocoaBackend) WindowMinimize(win unsafe.Pointer) {
+	C.Window_Minimize(win)
+}
+
+func (b cocoaBackend) WindowDeminimize(win unsafe.Pointer) {
+	C.Window_Deminimize(win)
+}
+
+func (b cocoaBackend) WindowZoom(win unsafe.Pointer) {
+	C.Window_Zoom(win)
+}
+
+func (b cocoaBackend) WindowCenter(win unsafe.Pointer) {
+	C.Window_Center(win)
+}
+
+func (b cocoaBackend) WindowToggleFullScreen(win unsafe.Pointer) {
+	C.Window_ToggleFullScreen(win)
+}
+
+func (b cocoaBackend) WindowClose(win unsafe.Pointer) {
+	C.Window_Close(win)
+}
+
+func (b cocoaBackend) Screens() []screenFrame {
+	count := int(C.Window_ScreenCount())
+	screens := make([]screenFrame, 0, count)
+
+	for i := 0; i < count; i++ {
+		frame := C.Window_ScreenFrame(C.int(i))
+		screens = append(screens, screenFrame{
+			X:      float64(frame.origin.x),
+			Y:      float64(frame.origin.y),
+			Width:  float64(frame.size.width),
+			Height: float64(frame.size.height),
+		})
+	}
+	return screens
+}
+
+//export onWindowCreated
+func onWindowCreated(cid *C.char, ptr unsafe.Pointer) {
+	handleWindowCreated(goUUID(cid), ptr)
+}
+
+//export onWindowWebviewLoaded
+func onWindowWebviewLoaded(cid *C.char) {
+	handleWindowWebviewLoaded(goUUID(cid))
+}
+
+//export onWindowWebviewNavigate
+func onWindowWebviewNavigate(cid *C.char, curl *C.char, navType C.int) bool {
+	return handleWindowWebviewNavigate(
+		goUUID(cid),
+		C.GoString(curl),
+		navigationTypeFromWK(int(navType)),
+	)
+}
+
+//export onWindowMinimize
+func onWindowMinimize(cid *C.char) {
+	handleWindowMinimize(goUUID(cid))
+}
+
+//export onWindowDeminimize
+func onWindowDeminimize(cid *C.char) {
+	handleWindowDeminimize(goUUID(cid))
+}
+
+//export onWindowFullScreen
+func onWindowFullScreen(cid *C.char) {
+	handleWindowFullScreen(goUUID(cid))
+}
+
+//export onWindowExitFullScreen
+func onWindowExitFullScreen(cid *C.char) {
+	handleWindowExitFullScreen(goUUID(cid))
+}
+
+//export onWindowMove
+func onWindowMove(cid *C.char, cx C.CGFloat, cy C.CGFloat) {
+	handleWindowMove(goUUID(cid), float64(cx), float64(cy))
+}
+
+//export onWindowResize
+func onWindowResize(cid *C.char, width C.CGFloat, height C.CGFloat) {
+	handleWindowResize(goUUID(cid), float64(width), float64(height))
+}
+
+//export onWindowFocus
+func onWindowFocus(cid *C.char) {
+	handleWindowFocus(goUUID(cid))
+}
+
+//export onWindowBlur
+func onWindowBlur(cid *C.char) {
+	handleWindowBlur(goUUID(cid))
+}
+
+//export onWindowClose
+func onWindowClose(cid *C.char) bool {
+	return handleWindowClose(goUUID(cid))
+}
+
+//export onWindowConfirm
+func onWindowConfirm(cid *C.char, cmessage *C.char) bool {
+	return handleWindowConfirm(goUUID(cid), C.GoString(cmessage))
+}
+
+//export onWindowPrompt
+func onWindowPrompt(cid *C.char, cmessage *C.char, cdefaultText *C.char) *C.char {
+	text, ok := handleWindowPrompt(
+		goUUID(cid),
+		C.GoString(cmessage),
+		C.GoString(cdefaultText),
+	)
+	if !ok {
+		return nil
+	}
+
+	// Freed by the webview delegate.
+	return C.CString(text)
+}
+
+//export onWindowHistory
+func onWindowHistory(cid *C.char, cdirection *C.char) {
+	handleWindowHistory(goUUID(cid), C.GoString(cdirection))
+}
+
+//export onWindowResourceRequest
+func onWindowResourceRequest(task unsafe.Pointer, curl *C.char) {
+	data, mimeType, status := handleResourceRequest(C.GoString(curl))
+
+	cmimeType := cString(mimeType)
+	defer free(unsafe.Pointer(cmimeType))
+
+	var cdata unsafe.Pointer
+	if len(data) != 0 {
+		cdata = unsafe.Pointer(&data[0])
+	}
+
+	C.Window_RespondResource(task, C.int(status), cmimeType, cdata, C.int(len(data)))
+}
+
+//export onWindowCloseFinal
+func onWindowCloseFinal(cid *C.char) {
+	handleWindowCloseFinal(goUUID(cid))
+}
+
+//export onWindowJSResult
+func onWindowJSResult(ccallID *C.char, cresultJSON *C.char, cerr *C.char) {
+	handleWindowJSResultJSON(goUUID(ccallID), C.GoString(cresultJSON), C.GoString(cerr))
+}
@@ -0,0 +1,311 @@
+package mac
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/murlokswarm/app"
+)
+
+type WindowComponent struct {
+	Greeting string
+}
+
+func (c *WindowComponent) Render() string {
+	return `<div>{{if .Greeting}}{{.Greeting}}{{else}}hello{{end}}</div>`
+}
+
+func init() {
+	app.RegisterComponent(&WindowComponent{})
+}
+
+func newTestWindow(t testing.TB, w app.Window) *window {
+	win, err := newWindow(Window{Window: w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return win
+}
+
+func TestWindow(t *testing.T) {
+	win := newTestWindow(t, app.Window{
+		X:      42,
+		Y:      21,
+		Width:  800,
+		Height: 600,
+	})
+
+	if x, y := win.Position(); x != 42 || y != 21 {
+		t.Errorf("position should be 42, 21: %v, %v", x, y)
+	}
+	if w, h := win.Size(); w != 800 || h != 600 {
+		t.Errorf("size should be 800, 600: %v, %v", w, h)
+	}
+	if _, ok := app.Elements().Get(win.ID()); !ok {
+		t.Error("window should be in the elements")
+	}
+}
+
+func TestWindowMoveResize(t *testing.T) {
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	win := newTestWindow(t, app.Window{
+		OnMove: func(x, y float64) {
+			wg.Done()
+		},
+		OnResize: func(width, height float64) {
+			wg.Done()
+		},
+	})
+
+	win.Move(100, 200)
+	win.Resize(300, 400)
+	wg.Wait()
+
+	if x, y := win.Position(); x != 100 || y != 200 {
+		t.Errorf("position should be 100, 200: %v, %v", x, y)
+	}
+	if w, h := win.Size(); w != 300 || h != 400 {
+		t.Errorf("size should be 300, 400: %v, %v", w, h)
+	}
+}
+
+func TestWindowMount(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	win.Mount(&WindowComponent{})
+
+	scripts := fakeNative().scripts(win.ptr)
+	if len(scripts) != 1 {
+		t.Fatalf("1 script should have been evaluated: %v", len(scripts))
+	}
+	if !strings.HasPrefix(scripts[0], "Mount(") {
+		t.Error("script should be a Mount call:", scripts[0])
+	}
+}
+
+func TestWindowClose(t *testing.T) {
+	closed := make(chan bool)
+
+	win := newTestWindow(t, app.Window{
+		OnClose: func() bool {
+			closed <- true
+			return true
+		},
+	})
+	win.Mount(&WindowComponent{})
+	win.Close()
+	<-closed
+
+	fakeNative().flush()
+	if _, ok := fakeNative().window(win.ptr); ok {
+		t.Error("native window should be closed")
+	}
+}
+
+func TestWindowEvents(t *testing.T) {
+	wg := sync.WaitGroup{}
+	wg.Add(4)
+
+	win := newTestWindow(t, app.Window{
+		OnMinimize:       wg.Done,
+		OnDeminimize:     wg.Done,
+		OnFullScreen:     wg.Done,
+		OnExitFullScreen: wg.Done,
+	})
+
+	fakeNative().minimizeWindow(win.ptr)
+	fakeNative().deminimizeWindow(win.ptr)
+	fakeNative().enterFullScreen(win.ptr)
+	fakeNative().exitFullScreen(win.ptr)
+	wg.Wait()
+}
+
+func TestWindowConcurrentCreation(t *testing.T) {
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			win, err := newWindow(Window{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			nwin, ok := fakeNative().window(win.ptr)
+			if !ok {
+				t.Error("native window not found")
+				return
+			}
+			if nwin.spec.ID != win.ID().String() {
+				t.Errorf("window %v is paired with native window %v", win.ID(), nwin.spec.ID)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWindowReload(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+
+	fakeNative().reload(win.ptr)
+	fakeNative().reload(win.ptr)
+	fakeNative().flush()
+
+	newTestWindow(t, app.Window{})
+}
+
+func TestWindowCreationTimeout(t *testing.T) {
+	fakeNative().stalled = true
+	driver.WindowTimeout = time.Millisecond * 10
+
+	defer func() {
+		fakeNative().stalled = false
+		driver.WindowTimeout = defaultWindowTimeout
+	}()
+
+	if _, err := newWindow(Window{}); err == nil {
+		t.Error("err should not be nil")
+	}
+}
+
+func TestWindowSetters(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	win.SetTitle("document.txt - edited")
+	win.SetBackgroundColor("#21252b")
+	win.SetVibrancy(app.VibeDark)
+	win.SetResizable(false)
+	win.SetMinMaxSize(100, 50, 0, 600)
+	fakeNative().flush()
+
+	nwin, ok := fakeNative().window(win.ptr)
+	if !ok {
+		t.Fatal("native window not found")
+	}
+	spec := nwin.spec
+
+	if spec.Title != "document.txt - edited" || win.config.Title != spec.Title {
+		t.Errorf("title should be updated: %v", spec.Title)
+	}
+	if spec.BackgroundColor != "#21252b" || win.config.BackgroundColor != spec.BackgroundColor {
+		t.Errorf("background color should be updated: %v", spec.BackgroundColor)
+	}
+	if spec.Vibrancy != int(app.VibeDark) || win.config.Vibrancy != app.VibeDark {
+		t.Errorf("vibrancy should be updated: %v", spec.Vibrancy)
+	}
+	if !spec.FixedSize || !win.config.FixedSize {
+		t.Error("window should not be resizable")
+	}
+	if spec.MinWidth != 100 || spec.MinHeight != 50 || spec.MaxWidth != 10000 || spec.MaxHeight != 600 {
+		t.Errorf("size limits should be 100, 50, 10000, 600: %v, %v, %v, %v",
+			spec.MinWidth, spec.MinHeight, spec.MaxWidth, spec.MaxHeight)
+	}
+}
+
+func TestWindowStateControls(t *testing.T) {
+	win := newTestWindow(t, app.Window{
+		Width:  800,
+		Height: 600,
+	})
+	defer win.Close()
+
+	if !win.IsVisible() {
+		t.Error("window should be visible")
+	}
+
+	win.Minimize()
+	fakeNative().flush()
+	if !win.IsMinimized() || win.IsVisible() {
+		t.Error("window should be minimized")
+	}
+
+	win.Deminimize()
+	fakeNative().flush()
+	if win.IsMinimized() || !win.IsVisible() {
+		t.Error("window should not be minimized")
+	}
+
+	win.ToggleFullScreen()
+	fakeNative().flush()
+	if !win.IsFullScreen() {
+		t.Error("window should be in full screen")
+	}
+
+	win.ToggleFullScreen()
+	fakeNative().flush()
+	if win.IsFullScreen() {
+		t.Error("window should not be in full screen")
+	}
+
+	win.Center()
+	fakeNative().flush()
+	if x, y := win.Position(); x != 560 || y != 240 {
+		t.Errorf("position should be 560, 240: %v, %v", x, y)
+	}
+
+	win.Zoom()
+	fakeNative().flush()
+	if w, h := win.Size(); w != 1920 || h != 1080 {
+		t.Errorf("size should be 1920, 1080: %v, %v", w, h)
+	}
+
+	win.Zoom()
+	fakeNative().flush()
+	if w, h := win.Size(); w != 800 || h != 600 {
+		t.Errorf("size should be 800, 600: %v, %v", w, h)
+	}
+}
+
+func TestWindowConfirmPrompt(t *testing.T) {
+	win, err := newWindow(Window{
+		OnConfirm: func(message string) bool {
+			return message == "delete?"
+		},
+		OnPrompt: func(message, defaultText string) (string, bool) {
+			return defaultText + ".txt", true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer win.Close()
+
+	if !handleWindowConfirm(win.ID(), "delete?") {
+		t.Error("confirm should be accepted")
+	}
+	if handleWindowConfirm(win.ID(), "quit?") {
+		t.Error("confirm should be refused")
+	}
+	if text, ok := handleWindowPrompt(win.ID(), "name?", "untitled"); !ok || text != "untitled.txt" {
+		t.Errorf("prompt should return untitled.txt: %v", text)
+	}
+}
+
+func TestWindowConfirmPromptNative(t *testing.T) {
+	fakeNative().setPanels(
+		func(message string) bool { return true },
+		func(message, defaultText string) (string, bool) { return "", false },
+	)
+	defer fakeNative().setPanels(nil, nil)
+
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	if !handleWindowConfirm(win.ID(), "delete?") {
+		t.Error("confirm should be answered by the native panel")
+	}
+	if _, ok := handleWindowPrompt(win.ID(), "name?", "untitled"); ok {
+		t.Error("prompt should be cancelled by the native panel")
+	}
+	if len(fakeNative().Calls("WindowConfirm")) == 0 {
+		t.Error("native confirm panel should be shown")
+	}
+}
@@ -0,0 +1,147 @@
+package mac
+
+import (
+	"net/url"
+	"reflect"
+
+	"github.com/murlokswarm/app"
+)
+
+// Windower is a window created by the driver.
+type Windower interface {
+	app.Windower
+	JSEvaluator
+
+	// Focus brings the window to front and makes it the key window.
+	Focus()
+
+	// Minimize minimizes the window into the dock.
+	Minimize()
+
+	// Deminimize restores the window from the dock.
+	Deminimize()
+
+	// ToggleFullScreen enters or exits the full screen mode.
+	ToggleFullScreen()
+
+	// Zoom toggles the window between its standard and user frames.
+	Zoom()
+
+	// Center moves the window to the center of its screen.
+	Center()
+
+	// IsMinimized reports whether the window is minimized.
+	IsMinimized() bool
+
+	// IsFullScreen reports whether the window is in full screen mode.
+	IsFullScreen() bool
+
+	// IsVisible reports whether the window is displayed on screen.
+	IsVisible() bool
+
+	// Back mounts the component of the previous history entry.
+	Back() error
+
+	// Forward mounts the component of the next history entry.
+	Forward() error
+
+	// CanGoBack reports whether the history has an entry before the current
+	// one.
+	CanGoBack() bool
+
+	// CanGoForward reports whether the history has an entry after the
+	// current one.
+	CanGoForward() bool
+
+	// History returns the URLs of the history and the index of the current
+	// one.
+	History() (entries []*url.URL, current int)
+
+	// SetTitle sets the title of the window.
+	SetTitle(title string)
+
+	// SetBackgroundColor sets the background color of the window.
+	SetBackgroundColor(color string)
+
+	// SetVibrancy sets the vibrancy effect displayed behind the window
+	// content.
+	SetVibrancy(v app.Vibrancy)
+
+	// SetResizable sets whether the window can be resized by the user.
+	SetResizable(resizable bool)
+
+	// SetMinMaxSize sets the size limits of the window.
+	SetMinMaxSize(minWidth, minHeight, maxWidth, maxHeight float64)
+}
+
+// Windows returns the open windows, in the order they have been created.
+func (d *Driver) Windows() []Windower {
+	d.windowsMutex.Lock()
+	defer d.windowsMutex.Unlock()
+
+	windows := make([]Windower, 0, len(d.windows))
+	for _, win := range d.windows {
+		windows = append(windows, win)
+	}
+	return windows
+}
+
+// WindowByComponent returns the first open window that displays a component
+// of the same type as c.
+// It should be called on the UI goroutine, where components are mounted.
+func (d *Driver) WindowByComponent(c app.Componer) (win Windower, ok bool) {
+	t := reflect.TypeOf(c)
+
+	for _, w := range d.Windows() {
+		if comp := w.Component(); comp != nil && reflect.TypeOf(comp) == t {
+			return w, true
+		}
+	}
+	return nil, false
+}
+
+// FocusedWindow returns the key window.
+func (d *Driver) FocusedWindow() (win Windower, ok bool) {
+	d.windowsMutex.Lock()
+	defer d.windowsMutex.Unlock()
+
+	if d.focusedWindow == nil {
+		return nil, false
+	}
+	return d.focusedWindow, true
+}
+
+func (d *Driver) addWindow(w *window) {
+	d.windowsMutex.Lock()
+	d.windows = append(d.windows, w)
+	d.windowsMutex.Unlock()
+}
+
+func (d *Driver) removeWindow(w *window) {
+	d.windowsMutex.Lock()
+	defer d.windowsMutex.Unlock()
+
+	if d.focusedWindow == w {
+		d.focusedWindow = nil
+	}
+
+	for i, win := range d.windows {
+		if win == w {
+			d.windows = append(d.windows[:i:i], d.windows[i+1:]...)
+			return
+		}
+	}
+}
+
+func (d *Driver) setFocusedWindow(w *window, focused bool) {
+	d.windowsMutex.Lock()
+	defer d.windowsMutex.Unlock()
+
+	if focused {
+		d.focusedWindow = w
+		return
+	}
+	if d.focusedWindow == w {
+		d.focusedWindow = nil
+	}
+}
@@ -0,0 +1,89 @@
+package mac
+
+import (
+	"testing"
+
+	"github.com/murlokswarm/app"
+)
+
+type OtherWindowComponent struct{}
+
+func (c *OtherWindowComponent) Render() string {
+	return `<p>other</p>`
+}
+
+func TestDriverWindows(t *testing.T) {
+	win1 := newTestWindow(t, app.Window{})
+	win2 := newTestWindow(t, app.Window{})
+	defer win1.Close()
+	defer win2.Close()
+
+	var idx1, idx2 = -1, -1
+	for i, w := range driver.Windows() {
+		switch w.ID() {
+		case win1.ID():
+			idx1 = i
+		case win2.ID():
+			idx2 = i
+		}
+	}
+
+	if idx1 == -1 || idx2 == -1 {
+		t.Fatal("windows should be listed")
+	}
+	if idx1 > idx2 {
+		t.Error("windows should be listed in creation order")
+	}
+}
+
+func TestDriverWindowByComponent(t *testing.T) {
+	win := newTestWindow(t, app.Window{})
+	defer win.Close()
+
+	found := make(chan bool)
+	app.UIChan <- func() {
+		win.component = &OtherWindowComponent{}
+
+		w, ok := driver.WindowByComponent(&OtherWindowComponent{})
+		found <- ok && w.ID() == win.ID()
+	}
+	if !<-found {
+		t.Error("window should be found by its component")
+	}
+
+	app.UIChan <- func() {
+		win.component = nil
+
+		_, ok := driver.WindowByComponent(&OtherWindowComponent{})
+		found <- ok
+	}
+	if <-found {
+		t.Error("window should not be found")
+	}
+}
+
+func TestDriverFocusedWindow(t *testing.T) {
+	win1 := newTestWindow(t, app.Window{})
+	win2 := newTestWindow(t, app.Window{})
+	defer win1.Close()
+	defer win2.Close()
+	fakeNative().flush()
+
+	if w, ok := driver.FocusedWindow(); !ok || w.ID() != win2.ID() {
+		t.Error("last shown window should be focused")
+	}
+
+	win1.Focus()
+	fakeNative().flush()
+
+	if w, ok := driver.FocusedWindow(); !ok || w.ID() != win1.ID() {
+		t.Error("window should be focused")
+	}
+
+	win1.Close()
+	fakeNative().flush()
+
+	if w, ok := driver.FocusedWindow(); ok && w.ID() == win1.ID() {
+		t.Error("closed window should not be focused")
+	}
+}